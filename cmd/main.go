@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"github.com/gbin/goncurses"
 	"github.com/ninjapanzer/gogol_channels/internal"
 	glog "github.com/ninjapanzer/gogol_channels/log"
@@ -10,11 +11,112 @@ import (
 	"math/rand"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 )
 
+// simulation is what the input loop needs from whichever world is running
+type simulation interface {
+	Paint(y, x int)
+	// SelectTool switches the mouse tool, returning false if key is not a tool for this rule
+	SelectTool(key renderer.Key) bool
+	// Brush reports whether the mouse paints random clusters rather than single cells
+	Brush() bool
+	TogglePause()
+	Step()
+}
+
+// world is the part of either engine the input loop drives, for any state type
+type world[S comparable] interface {
+	SetCell(y, x int, state S)
+	TogglePause()
+	Step()
+}
+
+// painter adapts a world to simulation. The brush paints whichever live state
+// the rule spawns, the other tools paint one exact state. Rules with their own
+// tools have no brush and start with conductor, or their lowest tool key.
+type painter[S comparable] struct {
+	world[S]
+	rule  internal.Rule[S]
+	rng   *rand.Rand
+	tools map[renderer.Key]S
+	tool  renderer.Key
+	brush bool
+}
+
+func newPainter[S comparable](w world[S], rule internal.Rule[S], rng *rand.Rand) *painter[S] {
+	var erase S
+	tools := map[renderer.Key]S{renderer.KEY_ERASE: erase}
+	tool, brush := renderer.Key(renderer.KEY_BRUSH), true
+	if toolbox, ok := rule.(internal.Toolbox[S]); ok && len(toolbox.Tools()) > 0 {
+		brush = false
+		tool = 0
+		for key, state := range toolbox.Tools() {
+			tools[key] = state
+			if tool == 0 || key < tool {
+				tool = key
+			}
+		}
+		if _, ok := tools[renderer.KEY_CONDUCTOR]; ok {
+			tool = renderer.KEY_CONDUCTOR
+		}
+	}
+	return &painter[S]{
+		world: w,
+		rule:  rule,
+		rng:   rng,
+		tools: tools,
+		tool:  tool,
+		brush: brush,
+	}
+}
+
+func (p *painter[S]) Paint(y, x int) {
+	if state, ok := p.tools[p.tool]; ok {
+		p.SetCell(y, x, state)
+		return
+	}
+	p.SetCell(y, x, p.rule.Spawn(p.rng))
+}
+
+func (p *painter[S]) SelectTool(key renderer.Key) bool {
+	if _, ok := p.tools[key]; !ok && (key != renderer.KEY_BRUSH || !p.brush) {
+		return false
+	}
+	p.tool = key
+	return true
+}
+
+func (p *painter[S]) Brush() bool {
+	return p.tool == renderer.KEY_BRUSH
+}
+
+// settings collects the command line choices needed to build a world
+type settings struct {
+	engine         string
+	mode           internal.Mode
+	seed           int64
+	boundary       internal.Boundary
+	neighborhood   internal.Neighborhood
+	divergencePath string
+	patternPath    string
+	noise          internal.Noise
+	clock          internal.Clock
+	maxStaleness   time.Duration
+	causality      internal.Causality
+	backpressure   internal.Backpressure
+	links          internal.LinkFaults
+	partitions     []internal.Partition
+}
+
 func main() {
+	// "gol trace" analyses the log of an earlier run instead of starting one
+	if len(os.Args) > 1 && os.Args[1] == "trace" {
+		os.Exit(trace(os.Args[2:]))
+	}
+
 	// Initialize random seed
 	rand.Seed(time.Now().UnixNano())
 
@@ -22,8 +124,198 @@ func main() {
 	rendererType := flag.String("renderer", "ncurses", "Renderer to use (ncurses or ebiten)")
 	readRate := flag.Int64("read-rate", 500, "Initial read rate in milliseconds")
 	broadcastRate := flag.Int64("broadcast-rate", 500, "Initial broadcast rate in milliseconds")
+	ruleString := flag.String("rule", "B3/S23", "Life-like rule in B/S notation (e.g. B36/S23 for HighLife), Hensel rule (e.g. B2-a/S12), Larger than Life rule (e.g. R5,C0,M1,S34..58,B34..45,NM), Generations rule in B/S/C notation (e.g. B2/S/C3 for Brian's Brain), immigration, quadlife, wireworld or lenia (e.g. lenia:R=5,mu=0.15,sigma=0.017,dt=0.1)")
+	modeString := flag.String("mode", "async", "Evolution mode (async or sync generation barrier)")
+	engine := flag.String("engine", "channel", "World implementation (channel or sequential reference)")
+	boundaryString := flag.String("boundary", "dead", "Edge topology (dead, alive, torus, cylinder or klein)")
+	neighborhoodString := flag.String("neighborhood", "moore", "Neighbor shape (moore, vonneumann or hex, with :R for a radius, e.g. moore:2)")
+	seed := flag.Int64("seed", 0, "Seed for the initial population (0 picks one from the clock)")
+	divergencePath := flag.String("divergence", "", "Run a sequential reference alongside the channel world and write per second divergence CSV to this file")
+	transitionProb := flag.Float64("transition-prob", 1, "Probability that a cell applies the transition its rule computed on each read cycle")
+	noiseBirth := flag.Float64("noise-birth", 0, "Probability that a dead cell is spontaneously born on each read cycle")
+	noiseDeath := flag.Float64("noise-death", 0, "Probability that a live cell spontaneously dies on each read cycle")
+	clockString := flag.String("clock", "fixed", "Distribution of per-cell read and broadcast periods around the global rates (fixed, uniform, normal or exponential)")
+	clockJitter := flag.Float64("clock-jitter", 0.2, "Spread of the per-cell clock distribution as a fraction of the global rates")
+	clockDrift := flag.Float64("clock-drift", 0, "Standard deviation of the random walk each cell's clock takes on every tick")
+	maxStaleness := flag.Int64("max-staleness", 0, "Drop neighbor messages that spent longer than this many milliseconds in flight (0 keeps them all)")
+	causalLog := flag.Bool("causal-log", false, "Log every state change with its Lamport time and causes for gol trace")
+	vectorClock := flag.Bool("vector-clock", false, "Keep a vector clock over each cell's neighborhood as well as the Lamport clock")
+	backpressureString := flag.String("backpressure", "block", "What a cell does when a broadcast channel is full (block, drop-newest, drop-oldest or coalesce); sync mode always blocks")
+	buffer := flag.Int("buffer", 1, "Capacity of every broadcast channel in messages")
+	linkLatency := flag.Int64("link-latency", 0, "Milliseconds every message spends on the link to each neighbor")
+	linkJitter := flag.Int64("link-jitter", 0, "Random extra link latency of up to this many milliseconds")
+	linkLoss := flag.Float64("link-loss", 0, "Probability that a link drops a message")
+	linkDuplicate := flag.Float64("link-duplicate", 0, "Probability that a link delivers a message twice")
+	linkReorder := flag.Float64("link-reorder", 0, "Probability that a link holds a message back until after the next one")
+	linkConfig := flag.String("link-config", "", "JSON file with link faults, replacing the --link-* flags")
+	scenarioPath := flag.String("scenario", "", "Scenario file of network partitions to cut and heal (e.g. a line \"at 5s split column 40 for 10s\")")
+	patternPath := flag.String("pattern", "", "WireWorld pattern file to load instead of a random population (requires --rule=wireworld)")
 	flag.Parse()
 
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	mode, err := internal.ParseMode(*modeString)
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	boundary, err := internal.ParseBoundary(*boundaryString)
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	neighborhood, err := internal.ParseNeighborhood(*neighborhoodString)
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	noise, err := internal.NewNoise(*transitionProb, *noiseBirth, *noiseDeath)
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	clockDistribution, err := internal.ParseClockDistribution(*clockString)
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	clock, err := internal.NewClock(clockDistribution, *clockJitter, *clockDrift)
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	policy, err := internal.ParseBackpressurePolicy(*backpressureString)
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	backpressure, err := internal.NewBackpressure(policy, *buffer)
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	links, err := internal.NewLinkFaults(time.Duration(*linkLatency)*time.Millisecond, time.Duration(*linkJitter)*time.Millisecond,
+		*linkLoss, *linkDuplicate, *linkReorder)
+	if *linkConfig != "" && err == nil {
+		links, err = loadLinkFaults(*linkConfig)
+	}
+	if err != nil {
+		println(err.Error())
+		os.Exit(2)
+	}
+	partitions := make([]internal.Partition, 0)
+	if *scenarioPath != "" {
+		partitions, err = loadScenario(*scenarioPath)
+		if err != nil {
+			println(err.Error())
+			os.Exit(2)
+		}
+	}
+	cfg := settings{
+		engine:         *engine,
+		mode:           mode,
+		seed:           *seed,
+		boundary:       boundary,
+		neighborhood:   neighborhood,
+		divergencePath: *divergencePath,
+		patternPath:    *patternPath,
+		noise:          noise,
+		clock:          clock,
+		maxStaleness:   time.Duration(*maxStaleness) * time.Millisecond,
+		causality:      internal.Causality{Log: *causalLog, Vector: *vectorClock},
+		backpressure:   backpressure,
+		links:          links,
+		partitions:     partitions,
+	}
+
+	// The rule decides the state type of every cell
+	var start func(ctx context.Context, r renderer.Renderer) (simulation, func(), error)
+	if *patternPath != "" && !strings.EqualFold(*ruleString, "wireworld") {
+		println("--pattern requires --rule=wireworld")
+		os.Exit(2)
+	}
+	if *divergencePath != "" && *engine == "sequential" {
+		println("--divergence requires --engine=channel")
+		os.Exit(2)
+	}
+	if strings.EqualFold(*ruleString, "wireworld") {
+		var pattern [][]internal.WireState
+		if *patternPath != "" {
+			pattern, err = loadWireWorldPattern(*patternPath)
+			if err != nil {
+				println(err.Error())
+				os.Exit(2)
+			}
+		}
+		start = func(ctx context.Context, r renderer.Renderer) (simulation, func(), error) {
+			return startWorld(ctx, r, internal.Rule[internal.WireState](internal.WireWorld), pattern, cfg)
+		}
+	} else if lower := strings.ToLower(*ruleString); strings.HasPrefix(lower, "immigration") || strings.HasPrefix(lower, "quadlife") {
+		rule, err := internal.ParseSpeciesRule(*ruleString)
+		if err != nil {
+			println(err.Error())
+			os.Exit(2)
+		}
+		start = func(ctx context.Context, r renderer.Renderer) (simulation, func(), error) {
+			return startWorld(ctx, r, internal.Rule[internal.Species](rule), nil, cfg)
+		}
+	} else if strings.HasPrefix(strings.ToLower(*ruleString), "lenia") {
+		rule, err := internal.ParseLeniaRule(*ruleString)
+		if err != nil {
+			println(err.Error())
+			os.Exit(2)
+		}
+		// Every cell within the kernel radius feeds the potential
+		cfg.neighborhood = rule.Neighborhood()
+		start = func(ctx context.Context, r renderer.Renderer) (simulation, func(), error) {
+			return startWorld(ctx, r, internal.Rule[float64](rule), nil, cfg)
+		}
+	} else if strings.HasPrefix(strings.ToUpper(*ruleString), "R") {
+		rule, err := internal.ParseLargerThanLifeRule(*ruleString)
+		if err != nil {
+			println(err.Error())
+			os.Exit(2)
+		}
+		// The rule's radius decides which cells each cell subscribes to
+		cfg.neighborhood = rule.Neighborhood()
+		start = func(ctx context.Context, r renderer.Renderer) (simulation, func(), error) {
+			return startWorld(ctx, r, internal.Rule[uint8](rule), nil, cfg)
+		}
+	} else if strings.Count(*ruleString, "/") == 2 {
+		rule, err := internal.ParseGenerationsRule(*ruleString)
+		if err != nil {
+			println(err.Error())
+			os.Exit(2)
+		}
+		start = func(ctx context.Context, r renderer.Renderer) (simulation, func(), error) {
+			return startWorld(ctx, r, internal.Rule[uint8](rule), nil, cfg)
+		}
+	} else if internal.IsHenselRule(*ruleString) {
+		rule, err := internal.ParseHenselRule(*ruleString)
+		if err != nil {
+			println(err.Error())
+			os.Exit(2)
+		}
+		if neighborhood != internal.Moore(1) {
+			println("Hensel rules require --neighborhood=moore")
+			os.Exit(2)
+		}
+		start = func(ctx context.Context, r renderer.Renderer) (simulation, func(), error) {
+			return startWorld(ctx, r, internal.Rule[bool](rule), nil, cfg)
+		}
+	} else {
+		rule, err := internal.ParseRule(*ruleString)
+		if err != nil {
+			println(err.Error())
+			os.Exit(2)
+		}
+		start = func(ctx context.Context, r renderer.Renderer) (simulation, func(), error) {
+			return startWorld(ctx, r, internal.Rule[bool](rule), nil, cfg)
+		}
+	}
+
 	closer := glog.InitLogger()
 	defer closer()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -53,8 +345,13 @@ func main() {
 	}
 	defer r.End()
 
-	cWorld := internal.NewChannelWorld[internal.ChannelCell](r, 0.13)
-	cWorld.Bootstrap()
+	world, stop, err := start(ctx, r)
+	if err != nil {
+		r.End()
+		println(err.Error())
+		os.Exit(2)
+	}
+	defer stop()
 
 	// Only call goncurses.Update() if using the ncurses renderer
 	if *rendererType == "ncurses" {
@@ -87,8 +384,12 @@ func main() {
 					// Don't initialize lastMouseX/Y here to ensure we generate cells on first click
 				}
 
-				// Always generate cells on first click, and on drag when position changes
-				if mx != lastMouseX || my != lastMouseY || (lastMouseX == 0 && lastMouseY == 0) {
+				if !world.Brush() {
+					// Every tool but the brush paints exactly the cell under the mouse
+					world.Paint(my, mx)
+					lastMouseX, lastMouseY = mx, my
+				} else if mx != lastMouseX || my != lastMouseY || (lastMouseX == 0 && lastMouseY == 0) {
+					// Always generate cells on first click, and on drag when position changes
 					// Create a randomized cluster of cells in a circle of radius 5
 					radius := 2 // Radius of 2 will create a circle of about 5 cells
 					for dy := -radius; dy <= radius; dy++ {
@@ -97,11 +398,7 @@ func main() {
 							if dx*dx + dy*dy <= radius*radius {
 								// Add randomness - only set some cells to alive
 								if rand.Float64() < 0.7 { // 70% chance of becoming alive
-									ny, nx := my+dy, mx+dx
-									if ny >= 0 && ny < len(cWorld.Cells()) && nx >= 0 && nx < len(cWorld.Cells()[ny]) {
-										target := cWorld.Cells()[ny][nx]
-										target.SilentSetState(true) // Set cells to alive
-									}
+									world.Paint(my+dy, mx+dx) // Set cells to alive
 								}
 							}
 						}
@@ -114,6 +411,12 @@ func main() {
 				// Reset drag state when mouse is released
 				isMouseDragging = false
 				glog.GetLogger().Debug("mouse released")
+			} else if ch == renderer.KEY_PAUSE { // Space toggles pause
+				world.TogglePause()
+			} else if ch == renderer.KEY_STEP { // 'n' steps one read cycle
+				world.Step()
+			} else if world.SelectTool(ch) { // Switch mouse tool
+				isMouseDragging = false
 			} else if ch == 'q' { // Quit on 'q' press
 				cancel()
 				return
@@ -150,3 +453,147 @@ func main() {
 		println("Done")
 	}
 }
+
+// startWorld builds and starts the engine picked on the command line for a
+// rule of any state type. The returned func stops it again.
+// A non nil pattern replaces the random starting population.
+func startWorld[S comparable](ctx context.Context, r renderer.Renderer, rule internal.Rule[S], pattern [][]S, cfg settings) (simulation, func(), error) {
+	brush := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	if cfg.engine == "sequential" {
+		height, width := r.Dimensions()
+		sWorld := internal.NewSequentialWorld(height, width, 0.13, rule)
+		sWorld.SetSeed(cfg.seed)
+		sWorld.SetBoundary(cfg.boundary)
+		sWorld.SetNeighborhood(cfg.neighborhood)
+		sWorld.SetPattern(pattern)
+		sWorld.SetRenderer(r)
+		if err := sWorld.Bootstrap(ctx); err != nil {
+			return nil, nil, err
+		}
+		go sWorld.Run(ctx)
+		return newPainter[S](sWorld, rule, brush), func() {}, nil
+	}
+
+	cWorld := internal.NewChannelWorld(r, 0.13, rule, internal.WithMode(cfg.mode), internal.WithSeed(cfg.seed), internal.WithBoundary(cfg.boundary), internal.WithNeighborhood(cfg.neighborhood), internal.WithNoise(cfg.noise), internal.WithClock(cfg.clock), internal.WithMaxStaleness(cfg.maxStaleness), internal.WithCausality(cfg.causality), internal.WithBackpressure(cfg.backpressure), internal.WithLinkFaults(cfg.links), internal.WithPartitions(cfg.partitions))
+	cWorld.SetPattern(pattern)
+	if err := cWorld.Bootstrap(ctx); err != nil {
+		return nil, nil, err
+	}
+	// Regions painted in the Ebiten renderer run at their own rates
+	r.SetRegionRateCallback(cWorld.SetRates)
+	sim := newPainter[S](cWorld, rule, brush)
+	if cfg.divergencePath == "" {
+		return sim, cWorld.Shutdown, nil
+	}
+
+	out, err := os.Create(cfg.divergencePath)
+	if err != nil {
+		glog.GetLogger().Error("Failed to create divergence file", "error", err)
+		return sim, cWorld.Shutdown, nil
+	}
+
+	// A headless reference started from the same seed
+	height, width := r.Dimensions()
+	reference := internal.NewSequentialWorld(height, width, 0.13, rule)
+	reference.SetSeed(cfg.seed)
+	reference.SetBoundary(cfg.boundary)
+	reference.SetNeighborhood(cfg.neighborhood)
+	reference.SetPattern(pattern)
+	if err := reference.Bootstrap(ctx); err != nil {
+		cWorld.Shutdown()
+		out.Close()
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	divergence := internal.NewDivergence(cWorld, reference, out)
+	go func() {
+		defer close(done)
+		divergence.Run(ctx)
+	}()
+	// Paints and steps reach the reference too
+	return newPainter[S](divergence, rule, brush), func() {
+		cWorld.Shutdown()
+		// The last samples are flushed once Run sees the cancellation
+		cancel()
+		<-done
+		out.Close()
+	}, nil
+}
+
+// trace rebuilds the happens-before graph of a run started with --causal-log
+// from its log and prints the causal chain behind one of a cell's births or
+// deaths. It returns the exit code.
+func trace(args []string) int {
+	flags := flag.NewFlagSet("trace", flag.ExitOnError)
+	logPath := flags.String("log", "app.log", "Log of a run started with --causal-log")
+	cell := flags.String("cell", "", "Cell whose birth or death to explain, as y-x (e.g. 12-30)")
+	lamport := flags.Uint64("lamport", 0, "Lamport time of the change to explain (0 picks the cell's last birth or death)")
+	depth := flags.Int("depth", 4, "How many steps back to follow the causal chain")
+	flags.Parse(args)
+
+	in, err := os.Open(*logPath)
+	if err != nil {
+		println(err.Error())
+		return 2
+	}
+	defer in.Close()
+	graph, err := internal.ReadCausalLog(in)
+	if err != nil {
+		println(err.Error())
+		return 2
+	}
+	if graph.Len() == 0 {
+		println("no causal entries in " + *logPath + ", was the run started with --causal-log?")
+		return 1
+	}
+
+	fmt.Printf("%d state changes, %d happens-before violations\n", graph.Len(), graph.Violations())
+	chain := graph.LongestChain()
+	fmt.Printf("Longest causal chain: %d changes, from %v to %v\n", len(chain), chain[0], chain[len(chain)-1])
+	if *cell == "" {
+		return 0
+	}
+
+	var target *internal.CausalEvent
+	for _, event := range graph.Events(*cell) {
+		if (*lamport == 0 && (event.Birth() || event.Death())) || event.Lamport == *lamport {
+			target = event
+		}
+	}
+	if target == nil {
+		println("no matching birth or death of cell " + *cell)
+		return 1
+	}
+	fmt.Println()
+	graph.WriteCauses(os.Stdout, target, *depth)
+	return 0
+}
+
+func loadLinkFaults(path string) (internal.LinkFaults, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return internal.ReliableLinks, err
+	}
+	defer in.Close()
+	return internal.LoadLinkFaults(in)
+}
+
+func loadScenario(path string) ([]internal.Partition, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return internal.ParseScenario(in)
+}
+
+func loadWireWorldPattern(path string) ([][]internal.WireState, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return internal.ParseWireWorldPattern(in)
+}
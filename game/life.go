@@ -1,6 +1,12 @@
 package game
 
-type Life interface {
-	State() bool
-	SetState(bool)
+// Life is anything holding a cell state of type S. Classic two state Life
+// uses bool, richer automata use small integers or floats. The zero value of
+// S is always the dead or quiescent state.
+type Life[S any] interface {
+	State() S
+	SetState(S)
 }
+
+// BoolLife is the default two state Life
+type BoolLife = Life[bool]
@@ -1,7 +1,9 @@
 package game
 
-type World[T Life] interface {
+import "context"
+
+type World[T Life[S], S any] interface {
 	Cells() [][]T
 	ComputeState()
-	Bootstrap()
+	Bootstrap(ctx context.Context) error
 }
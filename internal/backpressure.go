@@ -0,0 +1,116 @@
+package internal
+
+import "fmt"
+
+// BackpressurePolicy decides what a cell does when a channel it broadcasts on is full
+type BackpressurePolicy int
+
+const (
+	// Block waits until there is room, stalling the cell behind its slowest reader
+	Block BackpressurePolicy = iota
+	// DropNewest throws away the message that did not fit
+	DropNewest
+	// DropOldest evicts the oldest buffered message to make room for the new one
+	DropOldest
+	// Coalesce replaces everything still buffered with the new message
+	Coalesce
+)
+
+func ParseBackpressurePolicy(s string) (BackpressurePolicy, error) {
+	switch s {
+	case "block":
+		return Block, nil
+	case "drop-newest":
+		return DropNewest, nil
+	case "drop-oldest":
+		return DropOldest, nil
+	case "coalesce":
+		return Coalesce, nil
+	}
+	return Block, fmt.Errorf("unknown backpressure policy %q (expected block, drop-newest, drop-oldest or coalesce)", s)
+}
+
+func (p BackpressurePolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Coalesce:
+		return "coalesce"
+	}
+	return "block"
+}
+
+// Backpressure is the overflow policy and capacity of every broadcast and
+// subscriber channel in a world
+type Backpressure struct {
+	Policy BackpressurePolicy
+	Buffer int
+}
+
+// DefaultBackpressure blocks on single message channels
+var DefaultBackpressure = Backpressure{Policy: Block, Buffer: 1}
+
+// NewBackpressure checks that the channels can hold at least one message
+func NewBackpressure(policy BackpressurePolicy, buffer int) (Backpressure, error) {
+	if buffer < 1 {
+		return DefaultBackpressure, fmt.Errorf("buffer size %d must be at least 1", buffer)
+	}
+	return Backpressure{Policy: policy, Buffer: buffer}, nil
+}
+
+// deliver puts msg on ch according to the cell's backpressure policy. It only
+// returns false when the cell is shut down while blocking.
+func (c *ChannelCell[S]) deliver(ch chan CellMessage[S], msg CellMessage[S]) bool {
+	switch c.backpressure.Policy {
+	case DropNewest:
+		select {
+		case ch <- msg:
+		default:
+			c.statsDropped(1)
+		}
+		return true
+	case DropOldest:
+		for {
+			select {
+			case ch <- msg:
+				return true
+			default:
+			}
+			// The reader may have made room in the meantime, in which case nothing is evicted
+			select {
+			case <-ch:
+				c.statsDropped(1)
+			default:
+			}
+		}
+	case Coalesce:
+		coalesced := 0
+		for {
+			select {
+			case ch <- msg:
+				if coalesced > 0 {
+					c.statsCoalesced(coalesced)
+				}
+				return true
+			default:
+			}
+			for drained := false; !drained; {
+				select {
+				case <-ch:
+					coalesced++
+				default:
+					drained = true
+				}
+			}
+		}
+	}
+
+	select {
+	case ch <- msg:
+		return true
+	case <-c.done:
+		return false
+	}
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    BackpressurePolicy
+		buffered  []uint64
+		shutdown  bool
+		want      []uint64
+		delivered bool
+		dropped   int
+		coalesced int
+	}{
+		{name: "block with room", policy: Block, buffered: []uint64{1}, want: []uint64{1, 3}, delivered: true},
+		{name: "block gives up when shut down", policy: Block, buffered: []uint64{1, 2}, shutdown: true, want: []uint64{1, 2}},
+		{name: "drop newest", policy: DropNewest, buffered: []uint64{1, 2}, want: []uint64{1, 2}, delivered: true, dropped: 1},
+		{name: "drop oldest", policy: DropOldest, buffered: []uint64{1, 2}, want: []uint64{2, 3}, delivered: true, dropped: 1},
+		{name: "drop oldest with room", policy: DropOldest, buffered: []uint64{1}, want: []uint64{1, 3}, delivered: true},
+		{name: "coalesce", policy: Coalesce, buffered: []uint64{1, 2}, want: []uint64{3}, delivered: true, coalesced: 2},
+		{name: "coalesce with room", policy: Coalesce, buffered: []uint64{1}, want: []uint64{1, 3}, delivered: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChannelCell(false, Location{}, Rule[bool](Conway))
+			c.SetBackpressure(Backpressure{Policy: tt.policy, Buffer: 2})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.shutdown {
+				cancel()
+			}
+			c.bind(ctx)
+			dropped, coalesced := 0, 0
+			c.SetStatsFunc(func(event CellEvent) {
+				switch event.name {
+				case Dropped:
+					dropped += event.count
+				case Coalesced:
+					coalesced += event.count
+				}
+			})
+
+			ch := make(chan CellMessage[bool], 2)
+			for _, seq := range tt.buffered {
+				ch <- CellMessage[bool]{Seq: seq}
+			}
+			if delivered := c.deliver(ch, CellMessage[bool]{Seq: 3}); delivered != tt.delivered {
+				t.Errorf("delivered %v, want %v", delivered, tt.delivered)
+			}
+			close(ch)
+			got := make([]uint64, 0)
+			for msg := range ch {
+				got = append(got, msg.Seq)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("channel holds %v, want %v", got, tt.want)
+			}
+			if dropped != tt.dropped || coalesced != tt.coalesced {
+				t.Errorf("dropped %d coalesced %d, want %d and %d", dropped, coalesced, tt.dropped, tt.coalesced)
+			}
+		})
+	}
+}
+
+func TestNewBackpressureRejectsEmptyBuffers(t *testing.T) {
+	for _, buffer := range []int{0, -1} {
+		if b, err := NewBackpressure(DropOldest, buffer); err == nil {
+			t.Errorf("accepted buffer %d as %+v", buffer, b)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	glog "github.com/ninjapanzer/gogol_channels/log"
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// Causality controls what cells record about why their state changed. Every
+// cell keeps a Lamport clock regardless, which only advances on state changes
+// and is piggybacked on every broadcast.
+type Causality struct {
+	// Log writes every state change to the log with its Lamport time and the
+	// last message heard from each neighbor, which is what `gol trace` reads
+	Log bool
+	// Vector also keeps a vector clock over each cell's neighborhood
+	Vector bool
+}
+
+// causalMessage marks the log entries a CausalGraph is built from
+const causalMessage = "Causal"
+
+// CausalRef points at the state of cell as of Lamport time Lamport. It is
+// written as "y-x@lamport" in the log.
+type CausalRef struct {
+	Cell    string
+	Lamport uint64
+}
+
+func (r CausalRef) String() string {
+	return fmt.Sprintf("%s@%d", r.Cell, r.Lamport)
+}
+
+func parseCausalRef(s string) (CausalRef, error) {
+	cell, lamport, ok := strings.Cut(s, "@")
+	if !ok {
+		return CausalRef{}, fmt.Errorf("cause %q: expected cell@lamport", s)
+	}
+	l, err := strconv.ParseUint(lamport, 10, 64)
+	if err != nil {
+		return CausalRef{}, fmt.Errorf("cause %q: %w", s, err)
+	}
+	return CausalRef{Cell: cell, Lamport: l}, nil
+}
+
+// logCausal records a single state change for the trace
+func logCausal(cell string, lamport uint64, from, to, reason string, causes []CausalRef, vector map[Location]uint64) {
+	refs := make([]string, len(causes))
+	for i, cause := range causes {
+		refs[i] = cause.String()
+	}
+	args := []any{"cell", cell, "lamport", lamport, "from", from, "to", to, "reason", reason, "causes", refs}
+	if vector != nil {
+		entries := make(map[string]uint64, len(vector))
+		for location, clock := range vector {
+			entries[location.String()] = clock
+		}
+		args = append(args, "vector", entries)
+	}
+	glog.GetLogger().Info(causalMessage, args...)
+}
+
+// CausalEvent is one logged state change
+type CausalEvent struct {
+	Cell    string            `json:"cell"`
+	Lamport uint64            `json:"lamport"`
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+	Reason  string            `json:"reason"`
+	Vector  map[string]uint64 `json:"vector,omitempty"`
+	Causes  []CausalRef       `json:"-"`
+}
+
+func (e *CausalEvent) String() string {
+	return fmt.Sprintf("%s@%d %s -> %s (%s)", e.Cell, e.Lamport, e.From, e.To, e.Reason)
+}
+
+// Birth reports whether the cell came alive, Death whether it died
+func (e *CausalEvent) Birth() bool { return e.From == renderer.DeadGlyph && e.To != renderer.DeadGlyph }
+func (e *CausalEvent) Death() bool { return e.From != renderer.DeadGlyph && e.To == renderer.DeadGlyph }
+
+// CausalGraph is the happens-before graph of a run. An event happened before
+// another when it is an earlier change of the same cell or the change that set
+// the state a neighbor's message carried.
+type CausalGraph struct {
+	// cells holds the events of every cell in Lamport order
+	cells map[string][]*CausalEvent
+	// events holds every event in Lamport order
+	events []*CausalEvent
+}
+
+// ReadCausalLog builds a CausalGraph from the JSON log of a run with the causal
+// log enabled. Lines that are not causal entries are skipped.
+func ReadCausalLog(in io.Reader) (*CausalGraph, error) {
+	g := &CausalGraph{cells: make(map[string][]*CausalEvent)}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry struct {
+			Msg    string   `json:"msg"`
+			Causes []string `json:"causes"`
+			CausalEvent
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Msg != causalMessage {
+			continue
+		}
+		event := entry.CausalEvent
+		for _, cause := range entry.Causes {
+			ref, err := parseCausalRef(cause)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			event.Causes = append(event.Causes, ref)
+		}
+		g.cells[event.Cell] = append(g.cells[event.Cell], &event)
+		g.events = append(g.events, &event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, events := range g.cells {
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Lamport < events[j].Lamport })
+	}
+	sort.SliceStable(g.events, func(i, j int) bool { return g.events[i].Lamport < g.events[j].Lamport })
+	return g, nil
+}
+
+// Len is the number of events in the graph
+func (g *CausalGraph) Len() int {
+	return len(g.events)
+}
+
+// Events returns the changes of a single cell in Lamport order
+func (g *CausalGraph) Events(cell string) []*CausalEvent {
+	return g.cells[cell]
+}
+
+// Resolve finds the event that set the state ref describes, the last change of
+// ref.Cell no later than ref.Lamport. It returns nil for a cell still in its
+// starting state.
+func (g *CausalGraph) Resolve(ref CausalRef) *CausalEvent {
+	events := g.cells[ref.Cell]
+	i := sort.Search(len(events), func(i int) bool { return events[i].Lamport > ref.Lamport })
+	if i == 0 {
+		return nil
+	}
+	return events[i-1]
+}
+
+// Parents lists the events that directly happened before e: the previous
+// change of the same cell and the changes behind every neighbor state it saw
+func (g *CausalGraph) Parents(e *CausalEvent) []*CausalEvent {
+	parents := make([]*CausalEvent, 0, len(e.Causes)+1)
+	seen := make(map[*CausalEvent]bool)
+	add := func(parent *CausalEvent) {
+		if parent != nil && parent != e && !seen[parent] {
+			seen[parent] = true
+			parents = append(parents, parent)
+		}
+	}
+	if e.Lamport > 0 {
+		add(g.Resolve(CausalRef{Cell: e.Cell, Lamport: e.Lamport - 1}))
+	}
+	for _, cause := range e.Causes {
+		add(g.Resolve(cause))
+	}
+	return parents
+}
+
+// Violations counts edges whose parent does not have a smaller Lamport time,
+// which a correct run never produces
+func (g *CausalGraph) Violations() int {
+	violations := 0
+	for _, e := range g.events {
+		for _, parent := range g.Parents(e) {
+			if parent.Lamport >= e.Lamport {
+				violations++
+			}
+		}
+	}
+	return violations
+}
+
+// LongestChain returns the longest chain of events each of which happened
+// before the next, oldest first
+func (g *CausalGraph) LongestChain() []*CausalEvent {
+	length := make(map[*CausalEvent]int, len(g.events))
+	previous := make(map[*CausalEvent]*CausalEvent, len(g.events))
+	var last *CausalEvent
+	// Parents always have smaller Lamport times, so they are visited first
+	for _, e := range g.events {
+		length[e] = 1
+		for _, parent := range g.Parents(e) {
+			if length[parent]+1 > length[e] {
+				length[e] = length[parent] + 1
+				previous[e] = parent
+			}
+		}
+		if last == nil || length[e] > length[last] {
+			last = e
+		}
+	}
+
+	chain := make([]*CausalEvent, 0)
+	for e := last; e != nil; e = previous[e] {
+		chain = append([]*CausalEvent{e}, chain...)
+	}
+	return chain
+}
+
+// WriteCauses writes e and, indented below it, the changes that happened
+// directly before it, down to depth levels. Changes already written are only
+// referred to so shared causes do not repeat their whole history.
+func (g *CausalGraph) WriteCauses(out io.Writer, e *CausalEvent, depth int) {
+	g.writeCauses(out, e, 0, depth, make(map[*CausalEvent]bool))
+}
+
+func (g *CausalGraph) writeCauses(out io.Writer, e *CausalEvent, indent, depth int, written map[*CausalEvent]bool) {
+	prefix := strings.Repeat("  ", indent)
+	if written[e] {
+		fmt.Fprintf(out, "%s%v (see above)\n", prefix, e)
+		return
+	}
+	written[e] = true
+	fmt.Fprintf(out, "%s%v\n", prefix, e)
+	if depth == 0 {
+		return
+	}
+	for _, parent := range g.Parents(e) {
+		g.writeCauses(out, parent, indent+1, depth-1, written)
+	}
+}
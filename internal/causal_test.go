@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLamportClockMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		own   uint64
+		heard []uint64
+		want  uint64
+	}{
+		{name: "first change", want: 1},
+		{name: "moves past a later neighbor", own: 2, heard: []uint64{5}, want: 6},
+		{name: "keeps its own later time", own: 9, heard: []uint64{3}, want: 10},
+		{name: "takes the latest of all neighbors", own: 1, heard: []uint64{4, 7, 2}, want: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChannelCell(false, Location{Y: 1, X: 1}, Rule[bool](Conway))
+			c.lamport = tt.own
+			for i, lamport := range tt.heard {
+				c.AddNeighborState(false)
+				c.hear(i, CellMessage[bool]{Sender: Location{Y: 0, X: i}, Lamport: lamport})
+			}
+			c.recordChange(false, true, "born")
+			if c.lamport != tt.want {
+				t.Errorf("lamport %d after the change, want %d", c.lamport, tt.want)
+			}
+		})
+	}
+}
+
+func TestVectorClockMerge(t *testing.T) {
+	self := Location{Y: 1, X: 1}
+	left, right, far := Location{Y: 1, X: 0}, Location{Y: 1, X: 2}, Location{Y: 5, X: 5}
+	tests := []struct {
+		name     string
+		messages []CellMessage[bool]
+		want     map[Location]uint64
+	}{
+		{
+			name: "only its own entry before hearing anything",
+			want: map[Location]uint64{self: 1},
+		},
+		{
+			name:     "tracks the senders it heard",
+			messages: []CellMessage[bool]{{Sender: left, Vector: map[Location]uint64{left: 3}}},
+			want:     map[Location]uint64{self: 1, left: 3},
+		},
+		{
+			name: "takes the entrywise maximum of known neighbors",
+			messages: []CellMessage[bool]{
+				{Sender: left, Vector: map[Location]uint64{left: 3, right: 1}},
+				{Sender: right, Vector: map[Location]uint64{right: 4, left: 2}},
+			},
+			want: map[Location]uint64{self: 1, left: 3, right: 4},
+		},
+		{
+			name:     "ignores cells outside the neighborhood and its own entry",
+			messages: []CellMessage[bool]{{Sender: left, Vector: map[Location]uint64{left: 2, far: 9, self: 7}}},
+			want:     map[Location]uint64{self: 1, left: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChannelCell(false, self, Rule[bool](Conway))
+			c.SetCausality(Causality{Vector: true})
+			for i, msg := range tt.messages {
+				c.AddNeighborState(false)
+				c.hear(i, msg)
+			}
+			c.recordChange(false, true, "born")
+			if !maps.Equal(c.vector, tt.want) {
+				t.Errorf("vector %v, want %v", c.vector, tt.want)
+			}
+			if published := *c.vectorSnapshot.Load(); !maps.Equal(published, tt.want) {
+				t.Errorf("published vector %v, want %v", published, tt.want)
+			}
+		})
+	}
+}
+
+// causalLog is a small run: two painted cells cause a birth, which kills one of them
+const causalLog = `{"msg":"Causal","cell":"0-0","lamport":3,"from":"0","to":"-","reason":"died","causes":["1-1@2"]}
+{"msg":"Heartbeat","name":"0-0"}
+{"msg":"Causal","cell":"1-1","lamport":2,"from":"-","to":"0","reason":"born","causes":["0-0@1","0-1@1","1-0@0"]}
+{"msg":"Causal","cell":"0-0","lamport":1,"from":"-","to":"0","reason":"Painted","causes":[]}
+{"msg":"Causal","cell":"0-1","lamport":1,"from":"-","to":"0","reason":"Painted","causes":[]}
+`
+
+func TestCausalGraph(t *testing.T) {
+	g, err := ReadCausalLog(strings.NewReader(causalLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Len() != 4 {
+		t.Fatalf("%d events, want 4", g.Len())
+	}
+	death := g.Events("0-0")[1]
+
+	tests := []struct {
+		name string
+		got  []*CausalEvent
+		want []string
+	}{
+		{name: "events of a cell in Lamport order", got: g.Events("0-0"), want: []string{"0-0@1 - -> 0 (Painted)", "0-0@3 0 -> - (died)"}},
+		{name: "parents are the previous change and the causes", got: g.Parents(death), want: []string{"0-0@1 - -> 0 (Painted)", "1-1@2 - -> 0 (born)"}},
+		{name: "cells still in their starting state are no parents", got: g.Parents(g.Events("1-1")[0]), want: []string{"0-0@1 - -> 0 (Painted)", "0-1@1 - -> 0 (Painted)"}},
+		{name: "longest chain", got: g.LongestChain(), want: []string{"0-0@1 - -> 0 (Painted)", "1-1@2 - -> 0 (born)", "0-0@3 0 -> - (died)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, len(tt.got))
+			for i, e := range tt.got {
+				got[i] = e.String()
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+
+	if v := g.Violations(); v != 0 {
+		t.Errorf("%d violations, want none", v)
+	}
+	if !death.Death() || death.Birth() {
+		t.Errorf("%v is not a death", death)
+	}
+}
+
+func TestCausalGraphViolations(t *testing.T) {
+	// 2-2 claims to have heard 1-1 at a Lamport time not before its own
+	log := causalLog + `{"msg":"Causal","cell":"2-2","lamport":2,"from":"-","to":"0","reason":"born","causes":["1-1@2"]}` + "\n"
+	g, err := ReadCausalLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := g.Violations(); v != 1 {
+		t.Errorf("%d violations, want 1", v)
+	}
+}
+
+func TestReadCausalLogRejectsBadCauses(t *testing.T) {
+	for _, cause := range []string{"1-1", "1-1@x"} {
+		t.Run(cause, func(t *testing.T) {
+			log := `{"msg":"Causal","cell":"0-0","lamport":1,"from":"-","to":"0","reason":"born","causes":["` + cause + `"]}`
+			if _, err := ReadCausalLog(strings.NewReader(log)); err == nil {
+				t.Errorf("accepted cause %q", cause)
+			}
+		})
+	}
+}
+
+func TestWriteCauses(t *testing.T) {
+	g, err := ReadCausalLog(strings.NewReader(causalLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	death := g.Events("0-0")[1]
+
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{depth: 0, want: "0-0@3 0 -> - (died)\n"},
+		{depth: 1, want: "0-0@3 0 -> - (died)\n" +
+			"  0-0@1 - -> 0 (Painted)\n" +
+			"  1-1@2 - -> 0 (born)\n"},
+		{depth: 2, want: "0-0@3 0 -> - (died)\n" +
+			"  0-0@1 - -> 0 (Painted)\n" +
+			"  1-1@2 - -> 0 (born)\n" +
+			"    0-0@1 - -> 0 (Painted) (see above)\n" +
+			"    0-1@1 - -> 0 (Painted)\n"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		g.WriteCauses(&out, death, tt.depth)
+		if out.String() != tt.want {
+			t.Errorf("depth %d got\n%s\nwant\n%s", tt.depth, out.String(), tt.want)
+		}
+	}
+}
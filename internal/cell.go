@@ -1,9 +1,10 @@
 package internal
 
 import (
+	"context"
 	"github.com/ninjapanzer/gogol_channels/game"
 	glog "github.com/ninjapanzer/gogol_channels/log"
-	"math/bits"
+	"math/rand"
 	"sync/atomic"
 	"time"
 )
@@ -14,193 +15,791 @@ var (
 	GlobalBroadcastRate int64 = 500 // Default broadcast rate in milliseconds
 )
 
-type ChannelCell struct {
-	game.Life
-	state          bool
+// cellCommand is sent over a cell's control channel to freeze or advance its read cycle
+type cellCommand int
+
+const (
+	cmdPause cellCommand = iota
+	cmdResume
+	cmdStep
+)
+
+// cellSnapshot is the state and generation a cell last published
+type cellSnapshot[S comparable] struct {
+	state      S
+	generation uint64
+}
+
+type ChannelCell[S comparable] struct {
+	game.Life[S]
+	state          S
+	position       Location
 	location       string
 	readSpeed      time.Duration
 	broadcastSpeed time.Duration
-	ticker         *time.Ticker
-	neighborChans  []<-chan bool
-	neighborStates uint
-	broadcast      chan bool
-	renderFunc     func(bool)
+	readClock      cellClock
+	broadcastClock cellClock
+	// Rates painted onto this cell's region replace the global rates when set.
+	// The renderer's goroutine writes them, so they are only accessed atomically.
+	regionReadRate      int64
+	regionBroadcastRate int64
+	generation     uint64
+	// snapshot is what broadcasts and other goroutines see of state and
+	// generation. Only the read cycle writes those two fields, painting
+	// included since Paint hands its state over paints, and it publishes every
+	// change here so heartbeats never read them directly.
+	snapshot       atomic.Pointer[cellSnapshot[S]]
+	// seq numbers outgoing messages. Heartbeats and state changes come from
+	// different goroutines so it only moves atomically.
+	seq            uint64
+	neighborChans  []<-chan CellMessage[S]
+	neighborStates []S
+	// neighborSeqs is the last Seq heard from each neighbor
+	neighborSeqs   []uint64
+	// lamport orders state changes causally. Broadcasts read it while state
+	// changes and painting advance it, so it only moves atomically.
+	lamport        uint64
+	// seenLamport is the latest Lamport time heard from any neighbor
+	seenLamport    uint64
+	// neighborCauses is the sender and Lamport time of the last message heard
+	// from each neighbor, the causes logged with every state change
+	neighborCauses []CausalRef
+	causality      Causality
+	// vector is the vector clock over the neighborhood, owned by the read
+	// cycle. Broadcasts carry the copy published in vectorSnapshot.
+	vector         map[Location]uint64
+	vectorSnapshot atomic.Pointer[map[Location]uint64]
+	// maxStaleness drops messages older than this when they are read, zero keeps them all
+	maxStaleness   time.Duration
+	broadcast      chan CellMessage[S]
+	subscribers    []chan CellMessage[S]
+	backpressure   Backpressure
+	control        chan cellCommand
+	paints         chan S
+	paused         bool
+	done           <-chan struct{}
+	rule           Rule[S]
+	speciated      bool
+	noise          Noise
+	rng            *rand.Rand
+	renderFunc     func(S)
 	statsFunc      func(event CellEvent)
 }
 
-func NewChannelCell(state bool, location string) *ChannelCell {
-	readRate := time.Duration(atomic.LoadInt64(&GlobalReadRate))
-	broadcastRate := time.Duration(atomic.LoadInt64(&GlobalBroadcastRate))
-
-	b := &ChannelCell{
+func NewChannelCell[S comparable](state S, position Location, rule Rule[S]) *ChannelCell[S] {
+	b := &ChannelCell[S]{
 		state:          state,
-		location:       location,
-		readSpeed:      readRate,
-		broadcastSpeed: broadcastRate,
-		ticker:         time.NewTicker(broadcastRate * time.Millisecond),
-		neighborChans:  make([]<-chan bool, 0),
-		neighborStates: 0,
-		broadcast:      make(chan bool, 1),
+		position:       position,
+		location:       position.String(),
+		readClock:      cellClock{scale: 1},
+		broadcastClock: cellClock{scale: 1},
+		neighborChans:  make([]<-chan CellMessage[S], 0),
+		neighborStates: make([]S, 0),
+		neighborSeqs:   make([]uint64, 0),
+		neighborCauses: make([]CausalRef, 0),
+		broadcast:      make(chan CellMessage[S], 1),
+		subscribers:    make([]chan CellMessage[S], 0),
+		backpressure:   DefaultBackpressure,
+		control:        make(chan cellCommand, 4),
+		paints:         make(chan S, 16),
+		rule:           rule,
+		noise:          NoNoise,
 		statsFunc:      func(event CellEvent) {},
 	}
 
+	_, b.speciated = rule.(Speciated[S])
+	b.publishSnapshot()
+	b.readSpeed = b.readClock.period(b.readRate())
+	b.broadcastSpeed = b.broadcastClock.period(b.broadcastRate())
+
 	return b
 }
 
-func (c *ChannelCell) State() bool {
-	return c.state
+func (c *ChannelCell[S]) State() S {
+	return c.snapshot.Load().state
+}
+
+// Generation is the number of read cycles or generations the cell has completed
+func (c *ChannelCell[S]) Generation() uint64 {
+	return c.snapshot.Load().generation
 }
 
-func (c *ChannelCell) SetState(state bool) {
+func (c *ChannelCell[S]) SetState(state S) {
+	c.statsPopulation(c.state, state)
 	c.state = state
+	c.publishSnapshot()
 	c.renderFunc(c.state)
 	c.statsBroadcast()
-	c.broadcast <- state
+	c.send(c.message())
+}
+
+// publishSnapshot makes the current state and generation visible to broadcasts
+func (c *ChannelCell[S]) publishSnapshot() {
+	c.snapshot.Store(&cellSnapshot[S]{state: c.state, generation: c.generation})
+}
+
+// message builds the broadcast describing the cell as it last published itself
+func (c *ChannelCell[S]) message() CellMessage[S] {
+	snapshot := c.snapshot.Load()
+	msg := CellMessage[S]{
+		Sender:     c.position,
+		Generation: snapshot.generation,
+		Seq:        atomic.AddUint64(&c.seq, 1),
+		SentAt:     time.Now(),
+		Lamport:    atomic.LoadUint64(&c.lamport),
+		State:      snapshot.state,
+	}
+	if vector := c.vectorSnapshot.Load(); vector != nil {
+		msg.Vector = *vector
+	}
+	return msg
+}
+
+// send places a message on the broadcast channel following the backpressure
+// policy, giving up once the cell has been shut down
+func (c *ChannelCell[S]) send(msg CellMessage[S]) bool {
+	return c.deliver(c.broadcast, msg)
+}
+
+// command delivers a control command, giving up once the cell has been shut down
+func (c *ChannelCell[S]) command(cmd cellCommand) {
+	select {
+	case c.control <- cmd:
+	case <-c.done:
+	}
+}
+
+// bind ties the cell to the lifetime of ctx. It must be called before any of
+// the cell goroutines are started.
+func (c *ChannelCell[S]) bind(ctx context.Context) {
+	c.done = ctx.Done()
 }
 
-func (c *ChannelCell) SilentSetState(state bool) {
+func (c *ChannelCell[S]) SilentSetState(state S) {
+	c.statsPopulation(c.state, state)
 	c.state = state
+	c.publishSnapshot()
 	glog.GetLogger().Debug("Silent Set State:", "name", c.location, "state", c.state)
 	c.renderFunc(c.state)
 }
 
-func (c *ChannelCell) AddChannel(ch <-chan bool) {
+func (c *ChannelCell[S]) AddChannel(ch <-chan CellMessage[S]) {
 	c.neighborChans = append(c.neighborChans, ch)
 }
 
-func (c *ChannelCell) AddNeighborState(s uint) {
-	c.neighborStates <<= 1
-	c.neighborStates |= s
+// AddNeighborState seeds the last-known state of the most recently added
+// channel, keeping neighborStates in the same order as neighborChans.
+func (c *ChannelCell[S]) AddNeighborState(state S) {
+	c.neighborStates = append(c.neighborStates, state)
+	c.neighborSeqs = append(c.neighborSeqs, 0)
+	c.neighborCauses = append(c.neighborCauses, CausalRef{})
+}
+
+// Subscribe creates a dedicated channel for one neighbor. Every broadcast is
+// copied onto each subscriber channel so no neighbor can steal a message
+// meant for another. All subscriptions must happen before publish starts.
+func (c *ChannelCell[S]) Subscribe() <-chan CellMessage[S] {
+	ch := make(chan CellMessage[S], c.backpressure.Buffer)
+	c.subscribers = append(c.subscribers, ch)
+	return ch
 }
 
-func (c *ChannelCell) BroadcastChan() <-chan bool {
-	return c.broadcast
+// publish fans each message placed on the broadcast channel out to every subscriber
+func (c *ChannelCell[S]) publish(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-c.broadcast:
+			for _, sub := range c.subscribers {
+				if !c.deliver(sub, msg) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *ChannelCell[S]) Live(ctx context.Context) {
+	c.listenAndUpdate(ctx)
 }
 
-func (c *ChannelCell) Live() {
-	c.listenAndUpdate()
+// LiveInGenerations runs the cell in lockstep with its neighbors instead of on
+// its own clock. See runGenerations.
+func (c *ChannelCell[S]) LiveInGenerations(ctx context.Context) {
+	c.runGenerations(ctx)
 }
 
-func (c *ChannelCell) SetRenderer(r func(bool)) {
+func (c *ChannelCell[S]) SetRenderer(r func(S)) {
 	c.renderFunc = r
 }
 
-func (c *ChannelCell) SetStatsFunc(s func(event CellEvent)) {
+func (c *ChannelCell[S]) SetStatsFunc(s func(event CellEvent)) {
 	c.statsFunc = s
 }
 
-func (c *ChannelCell) heartbeat() {
+// SetNoise makes the cell unreliable, drawing every random decision from rng
+func (c *ChannelCell[S]) SetNoise(noise Noise, rng *rand.Rand) {
+	c.noise = noise
+	c.rng = rng
+}
+
+// SetMaxStaleness makes the cell ignore messages that spent longer than
+// maxStaleness in flight, so a slow link cannot feed it outdated states
+func (c *ChannelCell[S]) SetMaxStaleness(maxStaleness time.Duration) {
+	c.maxStaleness = maxStaleness
+}
+
+// SetCausality chooses whether state changes are logged for tracing and
+// whether the cell keeps a vector clock. It must be called before the cell
+// goroutines are started.
+func (c *ChannelCell[S]) SetCausality(causality Causality) {
+	c.causality = causality
+	c.vector = nil
+	c.vectorSnapshot.Store(nil)
+	if causality.Vector {
+		c.vector = map[Location]uint64{c.position: 0}
+		c.publishVector()
+	}
+}
+
+// Paint sets the state from outside the cell without broadcasting it. The
+// read cycle applies it between two reads, giving up once the cell has been
+// shut down.
+func (c *ChannelCell[S]) Paint(state S) {
+	select {
+	case c.paints <- state:
+	case <-c.done:
+	}
+}
+
+// applyPaints applies every paint already queued for the cell
+func (c *ChannelCell[S]) applyPaints() {
 	for {
-		// Use the current global broadcast rate
-		broadcastRate := time.Duration(atomic.LoadInt64(&GlobalBroadcastRate))
-		c.broadcastSpeed = broadcastRate
+		select {
+		case state := <-c.paints:
+			c.applyPaint(state)
+		default:
+			return
+		}
+	}
+}
+
+// applyPaint makes a painted state the cell's own. Nothing the cell heard
+// caused the change, so it starts a new causal chain.
+func (c *ChannelCell[S]) applyPaint(state S) {
+	oldState := c.state
+	if oldState == state {
+		return
+	}
+	lamport := c.advanceLamport(0)
+	c.SilentSetState(state)
+	if c.causality.Log {
+		logCausal(c.location, lamport, c.rule.Glyph(oldState), c.rule.Glyph(state), "Painted", nil, nil)
+	}
+}
+
+// SetBackpressure sizes the broadcast channel and every channel handed out by
+// Subscribe, and decides what happens when one of them is full. It must be
+// called before any neighbor subscribes.
+func (c *ChannelCell[S]) SetBackpressure(backpressure Backpressure) {
+	c.backpressure = backpressure
+	c.broadcast = make(chan CellMessage[S], backpressure.Buffer)
+}
+
+// SetClock gives the cell its own read and broadcast periods, drawing their
+// scale factors and drift generators from rng. It must be called before the
+// cell goroutines are started.
+func (c *ChannelCell[S]) SetClock(clock Clock, rng *rand.Rand) {
+	c.readClock = newCellClock(clock, rng)
+	c.broadcastClock = newCellClock(clock, rng)
+	c.readSpeed = c.readClock.period(c.readRate())
+	c.broadcastSpeed = c.broadcastClock.period(c.broadcastRate())
+}
+
+// SetRates pins the cell to its own read and broadcast rates in milliseconds
+// instead of the global ones. A rate of zero or less goes back to the global
+// rate. The cell picks the new rates up on its next tick.
+func (c *ChannelCell[S]) SetRates(readRate, broadcastRate int64) {
+	atomic.StoreInt64(&c.regionReadRate, readRate)
+	atomic.StoreInt64(&c.regionBroadcastRate, broadcastRate)
+}
+
+// readRate is the rate the cell's clock scales, its region's if one was
+// painted. The region rate is loaded once so a concurrent reset to zero
+// can't slip in between the check and the use.
+func (c *ChannelCell[S]) readRate() int64 {
+	if rate := atomic.LoadInt64(&c.regionReadRate); rate > 0 {
+		return rate
+	}
+	return atomic.LoadInt64(&GlobalReadRate)
+}
+
+// broadcastRate is the broadcast counterpart of readRate
+func (c *ChannelCell[S]) broadcastRate() int64 {
+	if rate := atomic.LoadInt64(&c.regionBroadcastRate); rate > 0 {
+		return rate
+	}
+	return atomic.LoadInt64(&GlobalBroadcastRate)
+}
+
+func (c *ChannelCell[S]) heartbeat(ctx context.Context) {
+	// Use the current broadcast rate scaled by the cell's own clock
+	c.broadcastSpeed = c.broadcastClock.period(c.broadcastRate())
+	ticker := time.NewTicker(c.broadcastSpeed)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 
-		time.Sleep(c.broadcastSpeed * time.Millisecond)
 		// Broadcast state regardless of whether the cell is alive or dead
 		c.statsHeartbeat()
 		glog.GetLogger().Debug("Heartbeat", "name", c.location)
 		c.statsBroadcast()
-		c.broadcast <- c.state
+		if !c.send(c.message()) {
+			return
+		}
+
+		// Pick up any change to the broadcast rate or drift of the cell's clock
+		if broadcastSpeed := c.broadcastClock.period(c.broadcastRate()); broadcastSpeed != c.broadcastSpeed {
+			c.broadcastSpeed = broadcastSpeed
+			ticker.Reset(c.broadcastSpeed)
+		}
 	}
 }
 
-func (c *ChannelCell) listenAndUpdate() {
-	// Map to store the latest states from the channels
-	var latestStates uint = 0
+func (c *ChannelCell[S]) listenAndUpdate(ctx context.Context) {
+	// Use the current read rate scaled by the cell's own clock
+	c.readSpeed = c.readClock.period(c.readRate())
+	timer := time.NewTimer(c.readSpeed)
+	defer timer.Stop()
 
-	for {
-		// Use the current global read rate
-		readRate := time.Duration(atomic.LoadInt64(&GlobalReadRate))
-		c.readSpeed = readRate
-
-		time.Sleep(c.readSpeed * time.Millisecond)
-		// Reset latestStates before each update cycle to avoid carrying over bits from previous cycles
-		latestStates = 0
-		for _, neighborChan := range c.neighborChans {
-			select {
-			case _ = <-neighborChan: // If a message is received on this channel
-				// Set the least significant bit to 1 to indicate a live neighbor
-				latestStates = (latestStates << 1) | 1
-				//gotUpdate = true
-			default:
-				// Set the least significant bit to 0 to indicate a dead neighbor
-				latestStates = (latestStates << 1) | 0
-			}
+	for c.wait(ctx, timer, c.readNeighbors) {
+		c.update()
+
+		c.readSpeed = c.readClock.period(c.readRate())
+		timer.Reset(c.readSpeed)
+	}
+}
+
+// runGenerations is the synchronous alternative to listenAndUpdate. The cell
+// broadcasts its generation N state, waits until it has heard generation N from
+// every neighbor and only then computes generation N+1. No cell can get more
+// than one generation ahead of its neighbors, so the whole network steps in
+// lockstep and reproduces classic Life exactly. The read rate only paces how
+// quickly generations advance.
+func (c *ChannelCell[S]) runGenerations(ctx context.Context) {
+	c.readSpeed = c.readClock.period(c.readRate())
+	timer := time.NewTimer(c.readSpeed)
+	defer timer.Stop()
+
+	// Draining while paused would swallow the generation messages the barrier waits for
+	for c.wait(ctx, timer, nil) {
+		c.statsBroadcast()
+		if !c.send(c.message()) {
+			return
+		}
+		if !c.awaitGeneration(ctx, c.generation) {
+			return
 		}
-		glog.GetLogger().Debug("Consumed", "Latest", latestStates)
 
-		// After checking all channels, update state based on the latest values
-		// For example, we can just print the new state based on the latest updates:
 		oldState := c.state
-		newState, reason := c.computeStateFromNeighbors(latestStates)
+		newState, reason := c.computeStateFromNeighbors()
+		c.generation++
 		if oldState != newState {
-			if newState {
-				c.SilentSetState(newState)
-			} else {
+			c.recordChange(oldState, newState, reason)
+			if isDead(newState) {
 				c.statsDied()
-				c.SetState(newState)
 			}
-			glog.GetLogger().Debug("Cell Updated", "name", c.location, "New State", c.state, "Reason", reason, "Old State", oldState)
+			// Neighbors hear about the change with the next generation's broadcast
+			c.SilentSetState(newState)
+			glog.GetLogger().Debug("Cell Updated", "name", c.location, "Generation", c.generation, "New State", c.state, "Reason", reason, "Old State", oldState)
+		} else {
+			c.publishSnapshot()
 		}
+
+		c.readSpeed = c.readClock.period(c.readRate())
+		timer.Reset(c.readSpeed)
 	}
 }
 
-func (c *ChannelCell) computeStateFromNeighbors(latestStates uint) (bool, string) {
+// wait blocks until the next read cycle is due, which is when the timer fires
+// while running or when a step command arrives while paused. Paints are
+// applied as they arrive. A paused cell
+// only calls drain on its timer, if there is one, so its neighbors' messages
+// keep flowing without it computing anything. It returns false once ctx is
+// cancelled.
+func (c *ChannelCell[S]) wait(ctx context.Context, timer *time.Timer, drain func()) bool {
+	for {
+		var tick <-chan time.Time
+		if !c.paused || drain != nil {
+			tick = timer.C
+		}
 
-	//Basic Rules:
-	//
-	//Any live cell with fewer than two live neighbors dies (underpopulation).
-	//Any live cell with two or three live neighbors continues to live (survival).
-	//Any live cell with more than three live neighbors dies (overpopulation).
-	//Any dead cell with exactly three live neighbors becomes a live cell (reproduction).
-	//
-	// Simple rule: a cell becomes alive if any of its neighbors are alive (just an example)
-	aliveCount := 0
-	// Use the latest states directly instead of XORing with previous states
-	c.neighborStates = latestStates
+		select {
+		case <-ctx.Done():
+			return false
+		case cmd := <-c.control:
+			// Paints sent before the command land first
+			c.applyPaints()
+			switch cmd {
+			case cmdPause:
+				c.paused = true
+			case cmdResume:
+				if c.paused {
+					c.paused = false
+					timer.Reset(c.readSpeed)
+				}
+			case cmdStep:
+				if c.paused {
+					return true
+				}
+			}
+		case state := <-c.paints:
+			c.applyPaint(state)
+		case <-tick:
+			if !c.paused {
+				return true
+			}
+			drain()
+			timer.Reset(c.readSpeed)
+		}
+	}
+}
 
-	aliveCount = bits.OnesCount(c.neighborStates)
+// update runs a single read cycle: collect neighbor states and apply the rule
+func (c *ChannelCell[S]) update() {
+	c.readNeighbors()
+	glog.GetLogger().Debug("Consumed", "Latest", c.neighborStates)
 
-	if c.state {
-		if aliveCount < 2 || aliveCount > 3 {
-			return false, "Under or Over Population"
+	// After checking all channels, update state based on the last known neighbor values
+	oldState := c.state
+	newState, reason := c.computeStateFromNeighbors()
+	c.generation++
+	if oldState != newState {
+		// The Lamport time moves first so the broadcast already carries it
+		c.recordChange(oldState, newState, reason)
+		if isDead(newState) {
+			c.statsDied()
+			c.SetState(newState)
 		} else {
-			return true, "Porridge Just Right"
+			c.SilentSetState(newState)
 		}
+		glog.GetLogger().Debug("Cell Updated", "name", c.location, "New State", c.state, "Reason", reason, "Old State", oldState)
 	} else {
-		if aliveCount == 3 {
-			c.statsResurrected()
-			return true, "Nobody Expects the Cellular Resurrection"
+		c.publishSnapshot()
+	}
+}
+
+// readNeighbors drains every neighbor channel without blocking and records the
+// most recent value received. A silent neighbor keeps its last known state.
+func (c *ChannelCell[S]) readNeighbors() {
+	latency := latencySample{}
+	for i, neighborChan := range c.neighborChans {
+		for drained := false; !drained; {
+			select {
+			case msg := <-neighborChan:
+				if c.accept(i, msg, &latency) {
+					c.neighborStates[i] = msg.State
+				}
+			default:
+				drained = true
+			}
 		}
 	}
+	c.statsLatency(latency)
+}
+
+// latencySample totals the time messages consumed in one read cycle spent in flight
+type latencySample struct {
+	count int
+	total time.Duration
+	peak  time.Duration
+}
 
-	return false, "Still Mostly Dead"
+func (l *latencySample) add(age time.Duration) {
+	l.count++
+	l.total += age
+	l.peak = max(l.peak, age)
 }
 
-func (c *ChannelCell) statsBroadcast() {
+// accept decides whether a message from neighbor i is fresh enough to use.
+// Messages that arrive behind a newer one from the same sender, or that spent
+// longer than maxStaleness in flight, are dropped.
+func (c *ChannelCell[S]) accept(i int, msg CellMessage[S], latency *latencySample) bool {
+	if msg.Seq <= c.neighborSeqs[i] {
+		c.statsOutOfOrder()
+		glog.GetLogger().Debug("Out of order", "name", c.location, "sender", msg.Sender.String(), "seq", msg.Seq, "last", c.neighborSeqs[i])
+		return false
+	}
+	c.neighborSeqs[i] = msg.Seq
+
+	age := time.Since(msg.SentAt)
+	if c.maxStaleness > 0 && age > c.maxStaleness {
+		c.statsStale()
+		glog.GetLogger().Debug("Stale", "name", c.location, "sender", msg.Sender.String(), "seq", msg.Seq, "age", age)
+		return false
+	}
+	latency.add(age)
+	c.hear(i, msg)
+	return true
+}
+
+// hear merges the causal clocks of an accepted message from neighbor i
+func (c *ChannelCell[S]) hear(i int, msg CellMessage[S]) {
+	c.seenLamport = max(c.seenLamport, msg.Lamport)
+	c.neighborCauses[i] = CausalRef{Cell: msg.Sender.String(), Lamport: msg.Lamport}
+	if c.vector == nil {
+		return
+	}
+	// Only the cell itself and the neighbors it has heard from are tracked
+	if _, ok := c.vector[msg.Sender]; !ok {
+		c.vector[msg.Sender] = 0
+	}
+	for location, clock := range msg.Vector {
+		if known, ok := c.vector[location]; ok && location != c.position {
+			c.vector[location] = max(known, clock)
+		}
+	}
+}
+
+// recordChange advances the causal clocks past everything the cell has heard
+// and, with the causal log enabled, logs the change with its causes
+func (c *ChannelCell[S]) recordChange(oldState, newState S, reason string) {
+	lamport := c.advanceLamport(c.seenLamport)
+	if c.vector != nil {
+		c.vector[c.position]++
+		c.publishVector()
+	}
+	if !c.causality.Log {
+		return
+	}
+
+	causes := make([]CausalRef, 0, len(c.neighborCauses))
+	for i, cause := range c.neighborCauses {
+		if c.neighborSeqs[i] > 0 {
+			causes = append(causes, cause)
+		}
+	}
+	logCausal(c.location, lamport, c.rule.Glyph(oldState), c.rule.Glyph(newState), reason, causes, c.vector)
+}
+
+// advanceLamport moves the Lamport clock past both its own time and seen and
+// returns the new time
+func (c *ChannelCell[S]) advanceLamport(seen uint64) uint64 {
+	for {
+		now := atomic.LoadUint64(&c.lamport)
+		next := max(now, seen) + 1
+		if atomic.CompareAndSwapUint64(&c.lamport, now, next) {
+			return next
+		}
+	}
+}
+
+// publishVector hands broadcasts a copy of the vector clock they can share
+func (c *ChannelCell[S]) publishVector() {
+	snapshot := make(map[Location]uint64, len(c.vector))
+	for location, clock := range c.vector {
+		snapshot[location] = clock
+	}
+	c.vectorSnapshot.Store(&snapshot)
+}
+
+// awaitGeneration blocks until every neighbor has reported its state for
+// generation. Anything older than generation is stale and skipped. A message
+// from a later generation means the neighbor's generation message was lost or
+// overtaken, which breaks the barrier, so the cell logs an error and stops.
+func (c *ChannelCell[S]) awaitGeneration(ctx context.Context, generation uint64) bool {
+	latency := latencySample{}
+	for i, neighborChan := range c.neighborChans {
+		if neighborChan == nil {
+			continue
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return false
+			case msg := <-neighborChan:
+				if msg.Generation < generation {
+					continue
+				}
+				if msg.Generation > generation {
+					glog.GetLogger().Error("Generation barrier broken", "name", c.location, "from", msg.Sender.String(), "expected", generation, "got", msg.Generation)
+					return false
+				}
+				c.neighborSeqs[i] = msg.Seq
+				latency.add(time.Since(msg.SentAt))
+				c.hear(i, msg)
+				c.neighborStates[i] = msg.State
+			}
+			break
+		}
+	}
+	c.statsLatency(latency)
+	return true
+}
+
+func (c *ChannelCell[S]) computeStateFromNeighbors() (S, string) {
+	newState, reason := c.rule.Next(c.state, c.neighborStates)
+	if c.noise.Enabled() {
+		newState, reason = c.applyNoise(newState, reason)
+	}
+	if isDead(c.state) && !isDead(newState) {
+		c.statsResurrected()
+	}
+	return newState, reason
+}
+
+// applyNoise randomly suppresses the computed transition and then injects
+// spontaneous births and deaths
+func (c *ChannelCell[S]) applyNoise(newState S, reason string) (S, string) {
+	if newState != c.state && c.rng.Float64() >= c.noise.Transition {
+		c.statsSuppressed()
+		newState, reason = c.state, "Not Today"
+	}
+
+	if isDead(newState) {
+		if c.noise.Birth > 0 && c.rng.Float64() < c.noise.Birth {
+			c.statsNoiseBirth()
+			return c.rule.Spawn(c.rng), "Spontaneous Generation"
+		}
+	} else if c.noise.Death > 0 && c.rng.Float64() < c.noise.Death {
+		c.statsNoiseDeath()
+		var dead S
+		return dead, "Struck by Lightning"
+	}
+	return newState, reason
+}
+
+// period converts a rate in milliseconds to a duration, never letting it reach
+// zero since tickers and timers reject non-positive periods
+func period(rate time.Duration) time.Duration {
+	if rate < 1 {
+		rate = 1
+	}
+	return rate * time.Millisecond
+}
+
+func (c *ChannelCell[S]) statsBroadcast() {
 	c.statsFunc(CellEvent{
 		name:  Broadcast,
 		count: 1,
 	})
 }
 
-func (c *ChannelCell) statsHeartbeat() {
+func (c *ChannelCell[S]) statsHeartbeat() {
 	c.statsFunc(CellEvent{
 		name:  Heartbeat,
 		count: 1,
 	})
 }
 
-func (c *ChannelCell) statsDied() {
+func (c *ChannelCell[S]) statsDied() {
 	c.statsFunc(CellEvent{
 		name:  Died,
 		count: 1,
 	})
 }
 
-func (c *ChannelCell) statsResurrected() {
+func (c *ChannelCell[S]) statsResurrected() {
 	c.statsFunc(CellEvent{
 		name:  Resurrected,
 		count: 1,
 	})
 }
+
+func (c *ChannelCell[S]) statsNoiseBirth() {
+	c.statsFunc(CellEvent{
+		name:  NoiseBirth,
+		count: 1,
+	})
+}
+
+func (c *ChannelCell[S]) statsNoiseDeath() {
+	c.statsFunc(CellEvent{
+		name:  NoiseDeath,
+		count: 1,
+	})
+}
+
+func (c *ChannelCell[S]) statsSuppressed() {
+	c.statsFunc(CellEvent{
+		name:  Suppressed,
+		count: 1,
+	})
+}
+
+func (c *ChannelCell[S]) statsDropped(count int) {
+	c.statsFunc(CellEvent{
+		name:  Dropped,
+		count: count,
+	})
+}
+
+func (c *ChannelCell[S]) statsCoalesced(count int) {
+	c.statsFunc(CellEvent{
+		name:  Coalesced,
+		count: count,
+	})
+}
+
+// statsLinkFault counts a fault one of the cell's outgoing links injected
+func (c *ChannelCell[S]) statsLinkFault(name string) {
+	c.statsFunc(CellEvent{
+		name:  name,
+		count: 1,
+	})
+}
+
+func (c *ChannelCell[S]) statsOutOfOrder() {
+	c.statsFunc(CellEvent{
+		name:  OutOfOrder,
+		count: 1,
+	})
+}
+
+func (c *ChannelCell[S]) statsStale() {
+	c.statsFunc(CellEvent{
+		name:  Stale,
+		count: 1,
+	})
+}
+
+// statsLatency reports the messages consumed in one read cycle, if there were any
+func (c *ChannelCell[S]) statsLatency(latency latencySample) {
+	if latency.count == 0 {
+		return
+	}
+	c.statsFunc(CellEvent{
+		name:    Latency,
+		count:   latency.count,
+		latency: latency.total,
+		peak:    latency.peak,
+	})
+}
+
+// statsPopulation moves the cell from the population of its old species to
+// that of its new one. Only rules with species are counted.
+func (c *ChannelCell[S]) statsPopulation(oldState, newState S) {
+	if !c.speciated || oldState == newState {
+		return
+	}
+	if !isDead(oldState) {
+		c.statsFunc(CellEvent{
+			name:    Population,
+			count:   -1,
+			species: c.rule.Glyph(oldState),
+		})
+	}
+	if !isDead(newState) {
+		c.statsFunc(CellEvent{
+			name:    Population,
+			count:   1,
+			species: c.rule.Glyph(newState),
+		})
+	}
+}
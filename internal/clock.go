@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ClockDistribution picks how far each cell's clock strays from the global rates
+type ClockDistribution int
+
+const (
+	// FixedClock runs every cell at exactly the global rates
+	FixedClock ClockDistribution = iota
+	// UniformClock scales each cell by a factor drawn evenly from 1-jitter to 1+jitter
+	UniformClock
+	// NormalClock scales each cell by a factor drawn from a normal distribution
+	// around 1 with jitter as the standard deviation
+	NormalClock
+	// ExponentialClock blends in an exponentially distributed factor with mean
+	// 1, so a few cells run far slower than the rest. Jitter 1 is fully exponential.
+	ExponentialClock
+)
+
+// Scale factors are clamped to this range so no cell stops or spins
+const (
+	minClockScale = 0.05
+	maxClockScale = 20
+)
+
+func ParseClockDistribution(s string) (ClockDistribution, error) {
+	switch s {
+	case "fixed":
+		return FixedClock, nil
+	case "uniform":
+		return UniformClock, nil
+	case "normal":
+		return NormalClock, nil
+	case "exponential":
+		return ExponentialClock, nil
+	}
+	return FixedClock, fmt.Errorf("unknown clock %q (expected fixed, uniform, normal or exponential)", s)
+}
+
+func (d ClockDistribution) String() string {
+	switch d {
+	case UniformClock:
+		return "uniform"
+	case NormalClock:
+		return "normal"
+	case ExponentialClock:
+		return "exponential"
+	}
+	return "fixed"
+}
+
+// Clock gives every cell its own read and broadcast periods. Each cell scales
+// the global rates by factors drawn once from Distribution, so the sliders
+// still speed up or slow down the whole world. With Drift above zero the
+// factors also take a random walk, moving by a normal step with Drift as the
+// standard deviation on every tick.
+type Clock struct {
+	Distribution ClockDistribution
+	Jitter       float64
+	Drift        float64
+}
+
+// GlobalClock ticks every cell at exactly the global rates
+var GlobalClock = Clock{Distribution: FixedClock}
+
+// NewClock checks that jitter and drift are not negative
+func NewClock(distribution ClockDistribution, jitter, drift float64) (Clock, error) {
+	if jitter < 0 {
+		return GlobalClock, fmt.Errorf("clock jitter %v must not be negative", jitter)
+	}
+	if drift < 0 {
+		return GlobalClock, fmt.Errorf("clock drift %v must not be negative", drift)
+	}
+	return Clock{Distribution: distribution, Jitter: jitter, Drift: drift}, nil
+}
+
+// Enabled reports whether any cell can tick at other than the global rates
+func (c Clock) Enabled() bool {
+	return (c.Distribution != FixedClock && c.Jitter > 0) || c.Drift > 0
+}
+
+// scale draws the factor a single cell multiplies one of the global rates by
+func (c Clock) scale(rng *rand.Rand) float64 {
+	scale := 1.0
+	switch c.Distribution {
+	case UniformClock:
+		scale += c.Jitter * (2*rng.Float64() - 1)
+	case NormalClock:
+		scale += c.Jitter * rng.NormFloat64()
+	case ExponentialClock:
+		scale += c.Jitter * (rng.ExpFloat64() - 1)
+	}
+	return clampScale(scale)
+}
+
+func clampScale(scale float64) float64 {
+	return math.Max(minClockScale, math.Min(maxClockScale, scale))
+}
+
+// cellClock turns one of the global rates into the period of a single cell.
+// Each is only used by the goroutine that owns it, so drift can draw from its
+// own generator without locking.
+type cellClock struct {
+	scale float64
+	drift float64
+	rng   *rand.Rand
+}
+
+func newCellClock(clock Clock, rng *rand.Rand) cellClock {
+	return cellClock{
+		scale: clock.scale(rng),
+		drift: clock.Drift,
+		rng:   rand.New(rand.NewSource(rng.Int63())),
+	}
+}
+
+// period returns how long to wait before the next tick at rate milliseconds, drifting first
+func (k *cellClock) period(rate int64) time.Duration {
+	if k.drift > 0 {
+		k.scale = clampScale(k.scale + k.drift*k.rng.NormFloat64())
+	}
+	return time.Duration(float64(period(time.Duration(rate))) * k.scale)
+}
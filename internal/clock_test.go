@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParseClockDistribution(t *testing.T) {
+	for _, want := range []ClockDistribution{FixedClock, UniformClock, NormalClock, ExponentialClock} {
+		t.Run(want.String(), func(t *testing.T) {
+			got, err := ParseClockDistribution(want.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("parsed as %v, want %v", got, want)
+			}
+		})
+	}
+	if d, err := ParseClockDistribution("poisson"); err == nil {
+		t.Errorf("parsed poisson as %v, want an error", d)
+	}
+}
+
+func TestClockScale(t *testing.T) {
+	tests := []struct {
+		name     string
+		clock    Clock
+		min, max float64
+	}{
+		{name: "fixed ignores jitter", clock: Clock{Distribution: FixedClock, Jitter: 0.5}, min: 1, max: 1},
+		{name: "uniform stays within jitter", clock: Clock{Distribution: UniformClock, Jitter: 0.2}, min: 0.8, max: 1.2},
+		{name: "normal never stops a cell", clock: Clock{Distribution: NormalClock, Jitter: 5}, min: minClockScale, max: maxClockScale},
+		{name: "exponential never stops a cell", clock: Clock{Distribution: ExponentialClock, Jitter: 1}, min: minClockScale, max: maxClockScale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 1000; i++ {
+				if scale := tt.clock.scale(rng); scale < tt.min || scale > tt.max {
+					t.Fatalf("scale %v, want between %v and %v", scale, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestCellClockPeriod(t *testing.T) {
+	tests := []struct {
+		name  string
+		clock Clock
+		rate  int64
+		want  time.Duration
+	}{
+		{name: "fixed clock runs at the rate", clock: GlobalClock, rate: 500, want: 500 * time.Millisecond},
+		{name: "zero rate still ticks", clock: GlobalClock, rate: 0, want: time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newCellClock(tt.clock, rand.New(rand.NewSource(1)))
+			if got := k.period(tt.rate); got != tt.want {
+				t.Errorf("period(%d) = %v, want %v", tt.rate, got, tt.want)
+			}
+		})
+	}
+
+	// Drift walks the scale but never past its bounds
+	k := newCellClock(Clock{Drift: 10}, rand.New(rand.NewSource(1)))
+	for i := 0; i < 1000; i++ {
+		if got := k.period(100); got < time.Duration(minClockScale*float64(100*time.Millisecond)) || got > time.Duration(maxClockScale*float64(100*time.Millisecond)) {
+			t.Fatalf("drifted period %v outside the clock bounds", got)
+		}
+	}
+}
+
+func TestNewClockRejects(t *testing.T) {
+	if c, err := NewClock(UniformClock, -0.1, 0); err == nil {
+		t.Errorf("accepted negative jitter as %+v", c)
+	}
+	if c, err := NewClock(UniformClock, 0.1, -1); err == nil {
+		t.Errorf("accepted negative drift as %+v", c)
+	}
+}
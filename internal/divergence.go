@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	glog "github.com/ninjapanzer/gogol_channels/log"
+)
+
+// DivergenceSample compares the channel world with its sequential reference at one instant
+type DivergenceSample struct {
+	Elapsed             time.Duration
+	Generation          uint64
+	Hamming             int
+	ChannelPopulation   int
+	ReferencePopulation int
+	// FirstDivergence is when the two worlds first disagreed, or -1 if they never have
+	FirstDivergence time.Duration
+}
+
+// PopulationDiff is how many more live cells the channel world has than the reference
+func (d DivergenceSample) PopulationDiff() int {
+	return d.ChannelPopulation - d.ReferencePopulation
+}
+
+func (d DivergenceSample) String() string {
+	first := "never"
+	if d.FirstDivergence >= 0 {
+		first = d.FirstDivergence.Round(time.Millisecond).String()
+	}
+	return fmt.Sprintf(
+		"Hamming: %v "+
+			"Pop diff: %v "+
+			"Ref gen: %v "+
+			"First divergence: %v",
+		d.Hamming,
+		d.PopulationDiff(),
+		d.Generation,
+		first)
+}
+
+var divergenceHeader = []string{
+	"elapsed_ms",
+	"reference_generation",
+	"hamming",
+	"channel_population",
+	"reference_population",
+	"population_diff",
+	"first_divergence_ms",
+}
+
+func (d DivergenceSample) record() []string {
+	first := int64(-1)
+	if d.FirstDivergence >= 0 {
+		first = d.FirstDivergence.Milliseconds()
+	}
+	return []string{
+		strconv.FormatInt(d.Elapsed.Milliseconds(), 10),
+		strconv.FormatUint(d.Generation, 10),
+		strconv.Itoa(d.Hamming),
+		strconv.Itoa(d.ChannelPopulation),
+		strconv.Itoa(d.ReferencePopulation),
+		strconv.Itoa(d.PopulationDiff()),
+		strconv.FormatInt(first, 10),
+	}
+}
+
+// Divergence runs a SequentialWorld alongside a ChannelWorld and measures how
+// far the asynchronous engine drifts from real Life. The reference advances
+// one generation per nominal read period, which is the pace a perfectly
+// synchronised channel world would keep. Painting and stepping through the
+// Divergence does the same to both worlds.
+type Divergence[S comparable] struct {
+	channel   *ChannelWorld[S]
+	reference *SequentialWorld[S]
+	out       *csv.Writer
+	first     time.Duration
+	// edits carries paints and steps to Run, which owns the reference.
+	// stopped is closed once Run returns.
+	edits   chan referenceEdit[S]
+	stopped chan struct{}
+}
+
+// referenceEdit is a paint, or a step when step is set, mirrored into the reference
+type referenceEdit[S comparable] struct {
+	step  bool
+	y, x  int
+	state S
+}
+
+// NewDivergence compares channel against reference, writing one CSV row per
+// second to out. out may be nil when only the on screen stats are wanted.
+// Both worlds must be the same size and seeded identically.
+func NewDivergence[S comparable](channel *ChannelWorld[S], reference *SequentialWorld[S], out io.Writer) *Divergence[S] {
+	d := &Divergence[S]{
+		channel:   channel,
+		reference: reference,
+		first:     -1,
+		edits:     make(chan referenceEdit[S], 64),
+		stopped:   make(chan struct{}),
+	}
+	if out != nil {
+		d.out = csv.NewWriter(out)
+	}
+	return d
+}
+
+// SetCell paints a cell of both worlds
+func (d *Divergence[S]) SetCell(y, x int, state S) {
+	d.channel.SetCell(y, x, state)
+	d.mirror(referenceEdit[S]{y: y, x: x, state: state})
+}
+
+// Step advances both worlds by one generation, pausing the channel world first if needed
+func (d *Divergence[S]) Step() {
+	d.channel.Step()
+	d.mirror(referenceEdit[S]{step: true})
+}
+
+// TogglePause pauses or resumes the channel world, the reference follows on its own
+func (d *Divergence[S]) TogglePause() {
+	d.channel.TogglePause()
+}
+
+// mirror hands an edit of the reference to Run, giving up once Run has returned
+func (d *Divergence[S]) mirror(edit referenceEdit[S]) {
+	select {
+	case d.edits <- edit:
+	case <-d.stopped:
+	}
+}
+
+// Run advances the reference and samples both worlds once a second until ctx
+// is cancelled. It applies the edits made through SetCell and Step, so it
+// must be running while they are called.
+func (d *Divergence[S]) Run(ctx context.Context) {
+	defer close(d.stopped)
+	start := time.Now()
+	generation := time.NewTimer(period(time.Duration(atomic.LoadInt64(&GlobalReadRate))))
+	defer generation.Stop()
+	sample := time.NewTicker(time.Second)
+	defer sample.Stop()
+
+	d.write(divergenceHeader)
+	for {
+		select {
+		case <-ctx.Done():
+			if d.out != nil {
+				d.out.Flush()
+			}
+			return
+		case <-generation.C:
+			if !d.channel.Paused() {
+				d.reference.ComputeState()
+			}
+			generation.Reset(period(time.Duration(atomic.LoadInt64(&GlobalReadRate))))
+		case edit := <-d.edits:
+			if edit.step {
+				d.reference.ComputeState()
+			} else {
+				d.reference.SetCell(edit.y, edit.x, edit.state)
+			}
+		case <-sample.C:
+			s := d.Sample(time.Since(start))
+			d.channel.s.SetDivergence(s)
+			d.write(s.record())
+		}
+	}
+}
+
+// Sample compares the two worlds cell by cell
+func (d *Divergence[S]) Sample(elapsed time.Duration) DivergenceSample {
+	s := DivergenceSample{
+		Elapsed:    elapsed,
+		Generation: d.reference.Generation(),
+	}
+	for y, row := range d.channel.Cells() {
+		for x, cell := range row {
+			channelState := cell.State()
+			referenceState := d.reference.Cells()[y][x].State()
+			if !isDead(channelState) {
+				s.ChannelPopulation++
+			}
+			if !isDead(referenceState) {
+				s.ReferencePopulation++
+			}
+			if channelState != referenceState {
+				s.Hamming++
+			}
+		}
+	}
+
+	if s.Hamming > 0 && d.first < 0 {
+		d.first = elapsed
+		glog.GetLogger().Info("Worlds diverged", "elapsed", elapsed, "generation", s.Generation, "hamming", s.Hamming)
+	}
+	s.FirstDivergence = d.first
+	return s
+}
+
+func (d *Divergence[S]) write(record []string) {
+	if d.out == nil {
+		return
+	}
+	if err := d.out.Write(record); err != nil {
+		glog.GetLogger().Error("Failed to write divergence", "error", err)
+	}
+	d.out.Flush()
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ninjapanzer/gogol_channels/renderer/mock"
+)
+
+func TestDivergenceMirrorsPaintsAndSteps(t *testing.T) {
+	// Neither world advances unless stepped
+	readRate := atomic.SwapInt64(&GlobalReadRate, int64(time.Hour/time.Millisecond))
+	t.Cleanup(func() { atomic.StoreInt64(&GlobalReadRate, readRate) })
+
+	channel := NewChannelWorld[bool](mock.NewMockRenderer(), 0.4, Conway, WithSeed(7), WithMode(Sync))
+	height, width := mock.NewMockRenderer().Dimensions()
+	r := &updateRenderer{Renderer: mock.NewMockRenderer(), updates: make(chan struct{}, 4)}
+	reference := NewSequentialWorld[bool](height, width, 0.4, Conway)
+	reference.SetSeed(7)
+	reference.SetRenderer(r)
+	if err := reference.Bootstrap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-r.updates
+	if err := channel.Bootstrap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer channel.Shutdown()
+	channel.Pause()
+
+	d := NewDivergence(channel, reference, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+
+	for x := 0; x < width; x++ {
+		d.SetCell(4, x, true)
+	}
+	d.Step()
+	awaitGeneration(t, channel, 1)
+	select {
+	case <-r.updates:
+	case <-time.After(time.Second):
+		t.Fatal("reference never stepped")
+	}
+	cancel()
+	<-done
+
+	if got, want := render(channel.Cells()), render(reference.Cells()); !slices.Equal(got, want) {
+		t.Errorf("channel world\n%v\nreference\n%v", got, want)
+	}
+	if s := d.Sample(time.Second); s.Hamming != 0 || s.Generation != 1 {
+		t.Errorf("sample %v, want no divergence at generation 1", s)
+	}
+}
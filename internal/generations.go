@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// dyingGlyphs names the refractory states of a Generations rule, counting down
+// to "1" for the last step before a cell is dead
+const dyingGlyphs = "123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// GenerationsRule extends a Life-like rule with refractory states. A live cell
+// that fails to survive does not die outright, it counts down through C-2
+// dying states and only then becomes dead. Dying cells cannot be born into and
+// do not count as live neighbors. States are numbered so the live state is
+// C-1, the dying states count down from C-2 to 1 and 0 is dead.
+type GenerationsRule struct {
+	name   string
+	life   LifeRule
+	states uint8
+}
+
+var _ Rule[uint8] = GenerationsRule{}
+
+// BriansBrain is the classic B2/S/C3 Generations rule
+var BriansBrain = MustParseGenerationsRule("B2/S/C3")
+
+// ParseGenerationsRule reads a rule in "B2/S/C3" notation. The unprefixed
+// survival/birth/states form used by most pattern collections, e.g. "345/2/4"
+// for Star Wars, is accepted as well.
+func ParseGenerationsRule(s string) (GenerationsRule, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 3 {
+		return GenerationsRule{}, fmt.Errorf("rule %q: expected three parts separated by '/'", s)
+	}
+
+	var bPart, sPart, cPart string
+	prefixed := false
+	for _, part := range parts {
+		upper := strings.ToUpper(part)
+		switch {
+		case strings.HasPrefix(upper, "B"):
+			bPart, prefixed = upper[1:], true
+		case strings.HasPrefix(upper, "S"):
+			sPart, prefixed = upper[1:], true
+		case strings.HasPrefix(upper, "C") || strings.HasPrefix(upper, "G"):
+			cPart, prefixed = upper[1:], true
+		default:
+			// "B2/S/3" leaves the state count bare
+			cPart = upper
+		}
+	}
+	if !prefixed {
+		// Survival/Birth/States without prefixes
+		sPart, bPart, cPart = parts[0], parts[1], parts[2]
+	}
+
+	states, err := strconv.Atoi(cPart)
+	if err != nil || states < 2 || states > len(dyingGlyphs)+2 {
+		return GenerationsRule{}, fmt.Errorf("rule %q: states must be a number between 2 and %d", s, len(dyingGlyphs)+2)
+	}
+	life, err := ParseRule("B" + bPart + "/S" + sPart)
+	if err != nil {
+		return GenerationsRule{}, fmt.Errorf("rule %q: %w", s, err)
+	}
+
+	return GenerationsRule{
+		name:   fmt.Sprintf("%s/C%d", life, states),
+		life:   life,
+		states: uint8(states),
+	}, nil
+}
+
+// MustParseGenerationsRule is ParseGenerationsRule for rules known at compile time.
+func MustParseGenerationsRule(s string) GenerationsRule {
+	r, err := ParseGenerationsRule(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r GenerationsRule) String() string {
+	return r.name
+}
+
+// Next only counts fully live neighbors. A dying cell keeps counting down
+// whatever its neighborhood looks like.
+func (r GenerationsRule) Next(state uint8, neighbors []uint8) (uint8, string) {
+	alive := r.Alive()
+	if state != 0 && state != alive {
+		return state - 1, "Pining for the Fjords"
+	}
+
+	count := 0
+	for _, n := range neighbors {
+		if n == alive {
+			count++
+		}
+	}
+
+	next, reason := r.life.next(state == alive, count)
+	switch {
+	case next:
+		return alive, reason
+	case state == alive:
+		// Survival failed, start counting down
+		return alive - 1, reason
+	}
+	return 0, reason
+}
+
+func (r GenerationsRule) Alive() uint8 {
+	return r.states - 1
+}
+
+func (r GenerationsRule) Spawn(rng *rand.Rand) uint8 {
+	return r.Alive()
+}
+
+func (r GenerationsRule) Glyph(state uint8) string {
+	switch {
+	case state == 0:
+		return renderer.DeadGlyph
+	case state == r.Alive():
+		return renderer.AliveGlyph
+	}
+	return dyingGlyphs[state-1 : state]
+}
+
+// Palette draws live cells off-white and dying cells blue, fading out as they
+// count down
+func (r GenerationsRule) Palette() renderer.Palette {
+	palette := renderer.Palette{
+		renderer.AliveGlyph: {Color: color.RGBA{240, 240, 240, 255}},
+	}
+	for state := uint8(1); state < r.Alive(); state++ {
+		palette[r.Glyph(state)] = renderer.Style{
+			Color: color.RGBA{64, 128, 255, 255},
+			Fade:  float64(state) / float64(r.Alive()),
+		}
+	}
+	return palette
+}
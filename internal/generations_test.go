@@ -0,0 +1,77 @@
+package internal
+
+import "testing"
+
+func TestParseGenerationsRule(t *testing.T) {
+	tests := []struct {
+		rule   string
+		want   string
+		states uint8
+	}{
+		{rule: "B2/S/C3", want: "B2/S/C3", states: 3},
+		{rule: "b2/s/c3", want: "B2/S/C3", states: 3},
+		{rule: "S/B2/C3", want: "B2/S/C3", states: 3},
+		{rule: "B2/S/3", want: "B2/S/C3", states: 3},
+		{rule: "B2/S/G3", want: "B2/S/C3", states: 3},
+		{rule: "345/2/4", want: "B2/S345/C4", states: 4},
+		{rule: " B3/S23/C2 ", want: "B3/S23/C2", states: 2},
+		{rule: "B3/S23/C63", want: "B3/S23/C63", states: 63},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseGenerationsRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.String() != tt.want {
+				t.Errorf("parsed as %q, want %q", r, tt.want)
+			}
+			if r.Alive() != tt.states-1 {
+				t.Errorf("live state %d, want %d", r.Alive(), tt.states-1)
+			}
+		})
+	}
+}
+
+func TestParseGenerationsRuleRejects(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"B2/S23",
+		"B2/S/C3/D4",
+		"B2/S/C1",
+		"B2/S/C64",
+		"B2/S/Cx",
+		"B9/S/C3",
+		"B2/S2a/C3",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			if r, err := ParseGenerationsRule(rule); err == nil {
+				t.Errorf("parsed as %q, want an error", r)
+			}
+		})
+	}
+}
+
+func TestBriansBrainCountsDown(t *testing.T) {
+	alive := BriansBrain.Alive()
+	two := []uint8{alive, alive, 0, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		name      string
+		state     uint8
+		neighbors []uint8
+		want      uint8
+	}{
+		{name: "dead cell with two live neighbors is born", state: 0, neighbors: two, want: alive},
+		{name: "live cell never survives", state: alive, neighbors: two, want: alive - 1},
+		{name: "dying cell ignores its neighbors", state: 1, neighbors: two, want: 0},
+		{name: "dying neighbors do not count", state: 0, neighbors: []uint8{alive, 1, 1, 0, 0, 0, 0, 0}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := BriansBrain.Next(tt.state, tt.neighbors); got != tt.want {
+				t.Errorf("Next(%d) = %d, want %d", tt.state, got, tt.want)
+			}
+		})
+	}
+}
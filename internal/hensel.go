@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"strings"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// henselLetters lists, for every neighbor count up to 4, one representative
+// arrangement of each Hensel letter. Bits are numbered clockwise from north in
+// the canonical Moore(1) link order: N, NE, E, SE, S, SW, W, NW. Counts 5 to 7
+// reuse the letters of 3 to 1 on the complement of the neighborhood.
+var henselLetters = map[int]map[byte][]int{
+	1: {
+		'c': {1},
+		'e': {0},
+	},
+	2: {
+		'c': {1, 3},
+		'e': {0, 2},
+		'k': {0, 3},
+		'a': {0, 1},
+		'i': {0, 4},
+		'n': {1, 5},
+	},
+	3: {
+		'c': {1, 3, 5},
+		'e': {0, 2, 4},
+		'k': {0, 2, 5},
+		'a': {0, 1, 2},
+		'i': {0, 1, 7},
+		'n': {0, 1, 3},
+		'y': {0, 3, 5},
+		'q': {0, 1, 5},
+		'j': {0, 1, 6},
+		'r': {0, 1, 4},
+	},
+	4: {
+		'c': {1, 3, 5, 7},
+		'e': {0, 2, 4, 6},
+		'k': {0, 1, 3, 6},
+		'a': {0, 1, 2, 3},
+		'i': {0, 1, 3, 4},
+		'n': {0, 1, 3, 7},
+		'y': {0, 1, 3, 5},
+		'q': {0, 1, 2, 5},
+		'j': {0, 1, 4, 6},
+		'r': {0, 1, 2, 4},
+		't': {0, 1, 4, 7},
+		'w': {0, 1, 5, 6},
+		'z': {0, 1, 4, 5},
+	},
+}
+
+// henselClass maps every arrangement of live neighbors to its Hensel letter,
+// or 0 for counts 0 and 8 which only have one arrangement
+var henselClass = buildHenselClasses()
+
+func buildHenselClasses() [256]byte {
+	var classes [256]byte
+	for count, letters := range henselLetters {
+		for letter, positions := range letters {
+			var mask uint8
+			for _, p := range positions {
+				mask |= 1 << p
+			}
+			for _, symmetric := range symmetries(mask) {
+				classes[symmetric] = letter
+				if count < 4 {
+					classes[^symmetric] = letter
+				}
+			}
+		}
+	}
+	return classes
+}
+
+// symmetries returns mask under every rotation and reflection of the square.
+// Rotating by 90 degrees moves each neighbor two places around the ring and
+// reflecting across the vertical axis maps position i to 8-i.
+func symmetries(mask uint8) []uint8 {
+	reflected := uint8(0)
+	for i := 0; i < 8; i++ {
+		if mask&(1<<i) != 0 {
+			reflected |= 1 << ((8 - i) % 8)
+		}
+	}
+
+	all := make([]uint8, 0, 8)
+	for turn := 0; turn < 8; turn += 2 {
+		all = append(all, bits.RotateLeft8(mask, turn), bits.RotateLeft8(reflected, turn))
+	}
+	return all
+}
+
+// HenselRule is an isotropic non-totalistic rule. Births and survivals depend
+// on the arrangement of the live neighbors, not just how many there are, so it
+// only works with the Moore(1) neighborhood whose links are in canonical order.
+type HenselRule struct {
+	name     string
+	birth    [256]bool
+	survival [256]bool
+}
+
+var _ Rule[bool] = HenselRule{}
+
+// IsHenselRule reports whether s uses Hensel letters and so needs
+// ParseHenselRule rather than ParseRule
+func IsHenselRule(s string) bool {
+	return strings.ContainsAny(strings.ToLower(s), "aceijknqrtwyz-")
+}
+
+// ParseHenselRule reads a rule in Hensel notation such as "B2-a/S12" or
+// "B3/S23-a4i". A count on its own accepts every arrangement, letters after a
+// count restrict it to those arrangements and a '-' excludes them instead.
+func ParseHenselRule(s string) (HenselRule, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 {
+		return HenselRule{}, fmt.Errorf("rule %q: expected two parts separated by '/'", s)
+	}
+
+	var bPart, sPart string
+	lower0, lower1 := strings.ToLower(parts[0]), strings.ToLower(parts[1])
+	switch {
+	case strings.HasPrefix(lower0, "b") && strings.HasPrefix(lower1, "s"):
+		bPart, sPart = lower0[1:], lower1[1:]
+	case strings.HasPrefix(lower0, "s") && strings.HasPrefix(lower1, "b"):
+		bPart, sPart = lower1[1:], lower0[1:]
+	default:
+		return HenselRule{}, fmt.Errorf("rule %q: expected B and S prefixes", s)
+	}
+
+	r := HenselRule{name: "B" + bPart + "/S" + sPart}
+	if err := parseHensel(bPart, &r.birth); err != nil {
+		return HenselRule{}, fmt.Errorf("rule %q: birth: %w", s, err)
+	}
+	if err := parseHensel(sPart, &r.survival); err != nil {
+		return HenselRule{}, fmt.Errorf("rule %q: survival: %w", s, err)
+	}
+	return r, nil
+}
+
+// parseHensel marks every neighbor arrangement accepted by one half of a rule
+func parseHensel(s string, accept *[256]bool) error {
+	for i := 0; i < len(s); {
+		if s[i] < '0' || s[i] > '8' {
+			return fmt.Errorf("invalid neighbor count %q", s[i])
+		}
+		count := int(s[i] - '0')
+		i++
+
+		exclude := i < len(s) && s[i] == '-'
+		if exclude {
+			i++
+		}
+		letters := make(map[byte]bool)
+		for ; i < len(s) && (s[i] < '0' || s[i] > '8'); i++ {
+			if _, ok := henselLetters[min(count, 8-count)][s[i]]; !ok {
+				return fmt.Errorf("count %d has no arrangement %q", count, s[i])
+			}
+			letters[s[i]] = true
+		}
+		if exclude && len(letters) == 0 {
+			return fmt.Errorf("count %d: '-' must be followed by letters", count)
+		}
+
+		for mask := 0; mask < 256; mask++ {
+			if bits.OnesCount8(uint8(mask)) != count {
+				continue
+			}
+			listed := letters[henselClass[mask]]
+			if len(letters) == 0 || listed != exclude {
+				accept[mask] = true
+			}
+		}
+	}
+	return nil
+}
+
+func (r HenselRule) String() string {
+	return r.name
+}
+
+// Next reads the neighbors as a bitmask in canonical link order
+func (r HenselRule) Next(alive bool, neighbors []bool) (bool, string) {
+	var mask uint8
+	for i, n := range neighbors {
+		if n && i < 8 {
+			mask |= 1 << i
+		}
+	}
+
+	if alive {
+		if r.survival[mask] {
+			return true, "Porridge Just Right"
+		}
+		return false, "Under or Over Population"
+	}
+	if r.birth[mask] {
+		return true, "Nobody Expects the Cellular Resurrection"
+	}
+	return false, "Still Mostly Dead"
+}
+
+func (r HenselRule) Alive() bool {
+	return true
+}
+
+func (r HenselRule) Spawn(rng *rand.Rand) bool {
+	return true
+}
+
+func (r HenselRule) Glyph(alive bool) string {
+	return Conway.Glyph(alive)
+}
+
+func (r HenselRule) Palette() renderer.Palette {
+	return Conway.Palette()
+}
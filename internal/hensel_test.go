@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"math/bits"
+	"testing"
+)
+
+// neighbors lists live neighbors by their position clockwise from north
+func neighbors(positions ...int) []bool {
+	live := make([]bool, 8)
+	for _, p := range positions {
+		live[p] = true
+	}
+	return live
+}
+
+func TestParseHenselRule(t *testing.T) {
+	tests := []struct {
+		rule      string
+		alive     bool
+		neighbors []bool
+		want      bool
+	}{
+		{rule: "B2-a/S12", neighbors: neighbors(0, 1), want: false},
+		{rule: "B2-a/S12", neighbors: neighbors(2, 3), want: false},
+		{rule: "B2-a/S12", neighbors: neighbors(0, 4), want: true},
+		{rule: "B2-a/S12", neighbors: neighbors(1, 3), want: true},
+		{rule: "B2-a/S12", alive: true, neighbors: neighbors(5), want: true},
+		{rule: "B2-a/S12", alive: true, neighbors: neighbors(0, 1), want: true},
+		{rule: "B2-a/S12", alive: true, neighbors: neighbors(0, 1, 2), want: false},
+		{rule: "B3/S23-a4i", alive: true, neighbors: neighbors(0, 1, 2), want: false},
+		{rule: "B3/S23-a4i", alive: true, neighbors: neighbors(0, 2, 4), want: true},
+		{rule: "B3/S23-a4i", alive: true, neighbors: neighbors(0, 1, 3, 4), want: true},
+		{rule: "B3/S23-a4i", alive: true, neighbors: neighbors(0, 1, 2, 3), want: false},
+		{rule: "B3/S23-a4i", neighbors: neighbors(0, 1, 2), want: true},
+		{rule: "b2e/s", neighbors: neighbors(2, 4), want: true},
+		{rule: "S/B2e", neighbors: neighbors(1, 3), want: false},
+		// Count 7 letters are those of count 1 on the dead neighbor
+		{rule: "B7c/S", neighbors: neighbors(0, 2, 3, 4, 5, 6, 7), want: true},
+		{rule: "B7c/S", neighbors: neighbors(1, 2, 3, 4, 5, 6, 7), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseHenselRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := r.Next(tt.alive, tt.neighbors); got != tt.want {
+				t.Errorf("Next(%v, %v) = %v, want %v", tt.alive, tt.neighbors, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHenselRuleWithoutLettersIsTotalistic(t *testing.T) {
+	hensel, err := ParseHenselRule("B3/S23")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for mask := 0; mask < 256; mask++ {
+		live := make([]bool, 8)
+		for i := range live {
+			live[i] = mask&(1<<i) != 0
+		}
+		for _, alive := range []bool{false, true} {
+			got, _ := hensel.Next(alive, live)
+			want, _ := Conway.next(alive, bits.OnesCount8(uint8(mask)))
+			if got != want {
+				t.Errorf("Next(%v, %08b) = %v, want %v", alive, mask, got, want)
+			}
+		}
+	}
+}
+
+func TestParseHenselRuleRejects(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"B2a",
+		"B2/S1/C3",
+		"23/3",
+		"B2x/S",
+		"B1k/S",
+		"B2-/S",
+		"B9/S",
+		"Ba/S",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			if r, err := ParseHenselRule(rule); err == nil {
+				t.Errorf("parsed as %q, want an error", r)
+			}
+		})
+	}
+}
+
+func TestIsHenselRule(t *testing.T) {
+	for rule, want := range map[string]bool{
+		"B3/S23":     false,
+		"23/3":       false,
+		"B2-a/S12":   true,
+		"B3/S23-a4i": true,
+	} {
+		if got := IsHenselRule(rule); got != want {
+			t.Errorf("IsHenselRule(%q) = %v, want %v", rule, got, want)
+		}
+	}
+}
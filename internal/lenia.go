@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// LeniaRule is a continuous automaton in the style of Bert Chan's Lenia. Every
+// state is a float64 between 0 and 1. A cell weighs the states of every
+// neighbor within radius R by a smooth ring shaped kernel, feeds the weighted
+// average through a gaussian growth function and moves a small step dt
+// towards where the growth points.
+type LeniaRule struct {
+	name   string
+	radius int
+	mu     float64
+	sigma  float64
+	dt     float64
+	// weights holds the normalized kernel for each neighbor in link order
+	weights []float64
+}
+
+var _ Rule[float64] = LeniaRule{}
+var _ Valued[float64] = LeniaRule{}
+
+// Lenia uses parameters that grow stable blobs at a radius cheap enough for
+// one goroutine per cell
+var Lenia = MustParseLeniaRule("lenia")
+
+// ParseLeniaRule reads "lenia", optionally followed by ":" and comma separated
+// parameters, e.g. "lenia:R=8,mu=0.15,sigma=0.017,dt=0.1"
+func ParseLeniaRule(s string) (LeniaRule, error) {
+	name, params, hasParams := strings.Cut(strings.TrimSpace(s), ":")
+	if !strings.EqualFold(name, "lenia") {
+		return LeniaRule{}, fmt.Errorf("unknown continuous rule %q (expected lenia)", s)
+	}
+
+	radius, mu, sigma, dt := 5, 0.15, 0.017, 0.1
+	if hasParams {
+		for _, param := range strings.Split(params, ",") {
+			key, value, ok := strings.Cut(param, "=")
+			if !ok {
+				return LeniaRule{}, fmt.Errorf("rule %q: expected key=value, got %q", s, param)
+			}
+			var err error
+			switch strings.ToLower(key) {
+			case "r":
+				radius, err = strconv.Atoi(value)
+				if err == nil && radius < 2 {
+					// Every Moore(1) neighbor lies on the rim, where the kernel vanishes
+					err = fmt.Errorf("must be at least 2")
+				}
+			case "mu":
+				mu, err = strconv.ParseFloat(value, 64)
+			case "sigma":
+				sigma, err = strconv.ParseFloat(value, 64)
+				if err == nil && sigma <= 0 {
+					err = fmt.Errorf("must be positive")
+				}
+			case "dt":
+				dt, err = strconv.ParseFloat(value, 64)
+				if err == nil && (dt <= 0 || dt > 1) {
+					err = fmt.Errorf("must be above 0 and at most 1")
+				}
+			default:
+				err = fmt.Errorf("unknown parameter")
+			}
+			if err != nil {
+				return LeniaRule{}, fmt.Errorf("rule %q: %s: %w", s, key, err)
+			}
+		}
+	}
+
+	r := LeniaRule{
+		name:   fmt.Sprintf("lenia:R=%d,mu=%v,sigma=%v,dt=%v", radius, mu, sigma, dt),
+		radius: radius,
+		mu:     mu,
+		sigma:  sigma,
+		dt:     dt,
+	}
+	weights, err := r.kernel()
+	if err != nil {
+		return LeniaRule{}, fmt.Errorf("rule %q: %w", s, err)
+	}
+	r.weights = weights
+	return r, nil
+}
+
+// MustParseLeniaRule is ParseLeniaRule for rules known at compile time.
+func MustParseLeniaRule(s string) LeniaRule {
+	r, err := ParseLeniaRule(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// kernel weighs every neighbor of the Moore(R) neighborhood by a bump that
+// peaks halfway out and vanishes at the centre and beyond R. It fails when no
+// neighbor gets any weight, since the weights could not be normalized.
+func (r LeniaRule) kernel() ([]float64, error) {
+	offsets := r.Neighborhood().offsets(0)
+	weights := make([]float64, len(offsets))
+	total := 0.0
+	for i, offset := range offsets {
+		distance := math.Hypot(float64(offset[0]), float64(offset[1])) / float64(r.radius)
+		if distance < 1 {
+			weights[i] = math.Exp(4 - 1/(distance*(1-distance)))
+		}
+		total += weights[i]
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("radius %d leaves the kernel empty", r.radius)
+	}
+	for i := range weights {
+		weights[i] /= total
+	}
+	return weights, nil
+}
+
+func (r LeniaRule) String() string {
+	return r.name
+}
+
+// Neighborhood is the square every kernel weight is laid out over. Worlds
+// running the rule must be linked with it.
+func (r LeniaRule) Neighborhood() Neighborhood {
+	return Moore(r.radius)
+}
+
+func (r LeniaRule) Next(state float64, neighbors []float64) (float64, string) {
+	potential := 0.0
+	for i, n := range neighbors {
+		if i < len(r.weights) {
+			potential += r.weights[i] * n
+		}
+	}
+
+	growth := 2*math.Exp(-math.Pow(potential-r.mu, 2)/(2*r.sigma*r.sigma)) - 1
+	next := math.Max(0, math.Min(1, state+r.dt*growth))
+	if growth > 0 {
+		return next, "Porridge Just Right"
+	}
+	return next, "Under or Over Population"
+}
+
+func (r LeniaRule) Alive() float64 {
+	return 1
+}
+
+func (r LeniaRule) Spawn(rng *rand.Rand) float64 {
+	return rng.Float64()
+}
+
+// Glyph only names a state for logs, both renderers draw Value instead
+func (r LeniaRule) Glyph(state float64) string {
+	if state == 0 {
+		return renderer.DeadGlyph
+	}
+	return strconv.FormatFloat(state, 'f', 2, 64)
+}
+
+func (r LeniaRule) Palette() renderer.Palette {
+	return renderer.Palette{}
+}
+
+func (r LeniaRule) Value(state float64) float64 {
+	return state
+}
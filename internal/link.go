@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// LinkFaults turns every edge between two cells into an unreliable network
+// link. Each message is delayed by Latency plus up to Jitter, lost with
+// probability Loss, delivered twice with probability Duplicate and held back
+// until after the next message with probability Reorder.
+type LinkFaults struct {
+	Latency   time.Duration
+	Jitter    time.Duration
+	Loss      float64
+	Duplicate float64
+	Reorder   float64
+}
+
+// ReliableLinks connect cells directly, without a link goroutine in between
+var ReliableLinks = LinkFaults{}
+
+// NewLinkFaults checks that the delays are not negative and every probability
+// lies between 0 and 1
+func NewLinkFaults(latency, jitter time.Duration, loss, duplicate, reorder float64) (LinkFaults, error) {
+	if latency < 0 || jitter < 0 {
+		return ReliableLinks, fmt.Errorf("link latency %v and jitter %v must not be negative", latency, jitter)
+	}
+	for _, p := range []struct {
+		name  string
+		value float64
+	}{{"link loss", loss}, {"link duplication", duplicate}, {"link reordering", reorder}} {
+		if p.value < 0 || p.value > 1 {
+			return ReliableLinks, fmt.Errorf("%s %v must be between 0 and 1", p.name, p.value)
+		}
+	}
+	return LinkFaults{Latency: latency, Jitter: jitter, Loss: loss, Duplicate: duplicate, Reorder: reorder}, nil
+}
+
+// LoadLinkFaults reads link faults from JSON such as
+// {"latency": 20, "jitter": 10, "loss": 0.05, "duplicate": 0.01, "reorder": 0.1}
+// with delays in milliseconds. Missing fields are left at zero.
+func LoadLinkFaults(in io.Reader) (LinkFaults, error) {
+	var config struct {
+		Latency   int64   `json:"latency"`
+		Jitter    int64   `json:"jitter"`
+		Loss      float64 `json:"loss"`
+		Duplicate float64 `json:"duplicate"`
+		Reorder   float64 `json:"reorder"`
+	}
+	decoder := json.NewDecoder(in)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return ReliableLinks, fmt.Errorf("link config: %w", err)
+	}
+	return NewLinkFaults(time.Duration(config.Latency)*time.Millisecond, time.Duration(config.Jitter)*time.Millisecond,
+		config.Loss, config.Duplicate, config.Reorder)
+}
+
+// Enabled reports whether links do anything to the messages crossing them
+func (f LinkFaults) Enabled() bool {
+	return f != ReliableLinks
+}
+
+// link carries messages from one cell to a single neighbor, mangling them on the way
+type link[S comparable] struct {
+	in     <-chan CellMessage[S]
+	out    chan CellMessage[S]
+	faults LinkFaults
+	rng    *rand.Rand
+	// sender delivers with its backpressure policy and counts the faults
+	sender *ChannelCell[S]
+	// drawFault shows a fault on the communications overlay
+	drawFault func(renderer.LinkFault)
+	// partitions are the scheduled partitions the link crosses
+	partitions []*scheduledPartition
+	// park holds messages sent across an active partition until it heals
+	// instead of dropping them
+	park bool
+	// fifo never lets jitter deliver a message ahead of one sent before it
+	fifo bool
+}
+
+// inFlight is a message waiting out its latency
+type inFlight[S comparable] struct {
+	due time.Time
+	msg CellMessage[S]
+}
+
+// run forwards messages from in to out until ctx is cancelled
+func (l *link[S]) run(ctx context.Context) {
+	pending := make([]inFlight[S], 0)
+	parked := make([]CellMessage[S], 0)
+	var held *CellMessage[S]
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		var healed <-chan struct{}
+		if p := l.partitioned(); p != nil && len(parked) > 0 {
+			healed = p.healed
+		} else {
+			pending, parked = l.unpark(pending, parked)
+		}
+
+		var due <-chan time.Time
+		if len(pending) > 0 {
+			timer.Reset(time.Until(pending[0].due))
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-l.in:
+			if l.partitioned() != nil {
+				l.sender.statsLinkFault(PartitionBlocked)
+				l.drawFault(renderer.LinkPartitioned)
+				if l.park {
+					parked = append(parked, msg)
+				}
+				continue
+			}
+			// Messages parked before a heal the loop has not seen yet go first
+			pending, parked = l.unpark(pending, parked)
+			if l.rng.Float64() < l.faults.Loss {
+				l.sender.statsLinkFault(LinkLost)
+				l.drawFault(renderer.LinkLost)
+				continue
+			}
+			if held == nil && l.rng.Float64() < l.faults.Reorder {
+				// Held back until the next message has been sent on its way
+				l.sender.statsLinkFault(LinkReordered)
+				l.drawFault(renderer.LinkReordered)
+				held = &msg
+				continue
+			}
+
+			pending = l.schedule(pending, msg)
+			if l.rng.Float64() < l.faults.Duplicate {
+				l.sender.statsLinkFault(LinkDuplicated)
+				l.drawFault(renderer.LinkDuplicated)
+				pending = l.schedule(pending, msg)
+			}
+			if held != nil {
+				pending = l.schedule(pending, *held)
+				held = nil
+			}
+		case <-healed:
+			// Parked messages are sent on their way at the top of the loop
+		case <-due:
+			for len(pending) > 0 && !pending[0].due.After(time.Now()) {
+				if !l.sender.deliver(l.out, pending[0].msg) {
+					return
+				}
+				pending = pending[1:]
+			}
+		}
+	}
+}
+
+// unpark sends every parked message on its way in the order it arrived
+func (l *link[S]) unpark(pending []inFlight[S], parked []CellMessage[S]) ([]inFlight[S], []CellMessage[S]) {
+	for _, msg := range parked {
+		pending = l.schedule(pending, msg)
+	}
+	return pending, parked[:0]
+}
+
+// partitioned returns an active partition the link crosses, or nil
+func (l *link[S]) partitioned() *scheduledPartition {
+	for _, p := range l.partitions {
+		if p.active() {
+			return p
+		}
+	}
+	return nil
+}
+
+// schedule queues msg behind everything due before it
+func (l *link[S]) schedule(pending []inFlight[S], msg CellMessage[S]) []inFlight[S] {
+	delay := l.faults.Latency
+	if l.faults.Jitter > 0 {
+		delay += time.Duration(l.rng.Int63n(int64(l.faults.Jitter) + 1))
+	}
+	due := time.Now().Add(delay)
+	if l.fifo && len(pending) > 0 && due.Before(pending[len(pending)-1].due) {
+		due = pending[len(pending)-1].due
+	}
+
+	i := sort.Search(len(pending), func(i int) bool { return pending[i].due.After(due) })
+	pending = append(pending, inFlight[S]{})
+	copy(pending[i+1:], pending[i:])
+	pending[i] = inFlight[S]{due: due, msg: msg}
+	return pending
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// testLink is a running link with both of its ends and the faults it drew
+type testLink struct {
+	in    chan CellMessage[bool]
+	out   chan CellMessage[bool]
+	drawn chan renderer.LinkFault
+	stop  func()
+}
+
+func runLink(faults LinkFaults, partitions []*scheduledPartition, park bool) *testLink {
+	ctx, cancel := context.WithCancel(context.Background())
+	sender := NewChannelCell(false, Location{}, Rule[bool](Conway))
+	sender.bind(ctx)
+	tl := &testLink{
+		in:    make(chan CellMessage[bool], 16),
+		out:   make(chan CellMessage[bool], 16),
+		drawn: make(chan renderer.LinkFault, 16),
+	}
+	l := &link[bool]{
+		in:         tl.in,
+		out:        tl.out,
+		faults:     faults,
+		rng:        rand.New(rand.NewSource(1)),
+		sender:     sender,
+		drawFault:  func(fault renderer.LinkFault) { tl.drawn <- fault },
+		partitions: partitions,
+		park:       park,
+	}
+	done := make(chan struct{})
+	go func() {
+		l.run(ctx)
+		close(done)
+	}()
+	tl.stop = func() {
+		cancel()
+		<-done
+	}
+	return tl
+}
+
+func (tl *testLink) send(seqs ...uint64) {
+	for _, seq := range seqs {
+		tl.in <- CellMessage[bool]{Seq: seq}
+	}
+}
+
+// received collects n messages, then waits a little to catch any extra ones
+func (tl *testLink) received(t *testing.T, n int) []uint64 {
+	t.Helper()
+	seqs := make([]uint64, 0)
+	deadline := time.After(time.Second)
+	for len(seqs) < n {
+		select {
+		case msg := <-tl.out:
+			seqs = append(seqs, msg.Seq)
+		case <-deadline:
+			t.Fatalf("received %v, want %d messages", seqs, n)
+		}
+	}
+	extra := time.After(20 * time.Millisecond)
+	for {
+		select {
+		case msg := <-tl.out:
+			seqs = append(seqs, msg.Seq)
+		case <-extra:
+			return seqs
+		}
+	}
+}
+
+// faults lists the faults drawn so far
+func (tl *testLink) faults() []renderer.LinkFault {
+	faults := make([]renderer.LinkFault, 0)
+	for {
+		select {
+		case fault := <-tl.drawn:
+			faults = append(faults, fault)
+		default:
+			return faults
+		}
+	}
+}
+
+func TestLinkFaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		faults LinkFaults
+		want   []uint64
+		drawn  []renderer.LinkFault
+	}{
+		{
+			name:   "latency keeps the order",
+			faults: LinkFaults{Latency: time.Millisecond},
+			want:   []uint64{1, 2, 3, 4},
+			drawn:  []renderer.LinkFault{},
+		},
+		{
+			name:   "loss",
+			faults: LinkFaults{Loss: 1},
+			want:   []uint64{},
+			drawn:  []renderer.LinkFault{renderer.LinkLost, renderer.LinkLost, renderer.LinkLost, renderer.LinkLost},
+		},
+		{
+			name:   "duplication",
+			faults: LinkFaults{Duplicate: 1},
+			want:   []uint64{1, 1, 2, 2, 3, 3, 4, 4},
+			drawn:  []renderer.LinkFault{renderer.LinkDuplicated, renderer.LinkDuplicated, renderer.LinkDuplicated, renderer.LinkDuplicated},
+		},
+		{
+			name:   "reordering holds a message until after the next",
+			faults: LinkFaults{Reorder: 1},
+			want:   []uint64{2, 1, 4, 3},
+			drawn:  []renderer.LinkFault{renderer.LinkReordered, renderer.LinkReordered},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := runLink(tt.faults, nil, false)
+			l.send(1, 2, 3, 4)
+			got := l.received(t, len(tt.want))
+			l.stop()
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("received %v, want %v", got, tt.want)
+			}
+			if drawn := l.faults(); !slices.Equal(drawn, tt.drawn) {
+				t.Errorf("drew %v, want %v", drawn, tt.drawn)
+			}
+		})
+	}
+}
+
+func TestLinkPartitions(t *testing.T) {
+	for _, park := range []bool{false, true} {
+		name := "drops"
+		if park {
+			name = "parks until healed"
+		}
+		t.Run(name, func(t *testing.T) {
+			p := &scheduledPartition{started: make(chan struct{}), healed: make(chan struct{})}
+			close(p.started)
+			l := runLink(LinkFaults{Latency: time.Millisecond}, []*scheduledPartition{p}, park)
+			defer l.stop()
+
+			l.send(1, 2, 3)
+			for i := 0; i < 3; i++ {
+				if fault := <-l.drawn; fault != renderer.LinkPartitioned {
+					t.Fatalf("drew %v, want %v", fault, renderer.LinkPartitioned)
+				}
+			}
+			close(p.healed)
+			l.send(4)
+
+			want := []uint64{4}
+			if park {
+				want = []uint64{1, 2, 3, 4}
+			}
+			if got := l.received(t, len(want)); !slices.Equal(got, want) {
+				t.Errorf("received %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// LargerThanLifeRule is a range-R totalistic rule in Evans' Larger than Life
+// family. Each cell listens to every cell within radius R, a Moore square or a
+// von Neumann diamond, and is born or survives when the live count falls in a
+// range. With more than two states a cell that fails to survive decays through
+// refractory states exactly like a Generations rule.
+type LargerThanLifeRule struct {
+	name         string
+	neighborhood Neighborhood
+	middle       bool
+	states       uint8
+	birthMin     int
+	birthMax     int
+	survivalMin  int
+	survivalMax  int
+	// decay draws the refractory states
+	decay GenerationsRule
+}
+
+var _ Rule[uint8] = LargerThanLifeRule{}
+
+// Bosco is Bosco's rule, the best known Larger than Life rule
+var Bosco = MustParseLargerThanLifeRule("R5,C0,M1,S34..58,B34..45,NM")
+
+// ParseLargerThanLifeRule reads a rule such as "R5,C0,M1,S34..58,B34..45,NM".
+// R is the radius, C the number of states (0 and 2 both mean plain two state
+// Life), M1 counts the cell itself, S and B are inclusive ranges and NM or NN
+// pick the Moore or von Neumann neighborhood. C, M and N are optional.
+func ParseLargerThanLifeRule(s string) (LargerThanLifeRule, error) {
+	r := LargerThanLifeRule{states: 2, birthMin: -1, survivalMin: -1}
+	radius := 0
+	vonNeumann := false
+
+	for _, field := range strings.Split(strings.ToUpper(strings.TrimSpace(s)), ",") {
+		if field == "" {
+			return LargerThanLifeRule{}, fmt.Errorf("rule %q: empty field", s)
+		}
+		value := field[1:]
+		var err error
+		switch field[0] {
+		case 'R':
+			radius, err = strconv.Atoi(value)
+			if err == nil && radius < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+		case 'C':
+			var states int
+			states, err = strconv.Atoi(value)
+			switch {
+			case err != nil:
+			case states == 0:
+				states = 2
+			case states < 2 || states > len(dyingGlyphs)+2:
+				err = fmt.Errorf("must be 0 or between 2 and %d", len(dyingGlyphs)+2)
+			}
+			r.states = uint8(states)
+		case 'M':
+			switch value {
+			case "0":
+			case "1":
+				r.middle = true
+			default:
+				err = fmt.Errorf("must be 0 or 1")
+			}
+		case 'S':
+			r.survivalMin, r.survivalMax, err = parseRange(value)
+		case 'B':
+			r.birthMin, r.birthMax, err = parseRange(value)
+		case 'N':
+			switch value {
+			case "M":
+			case "N":
+				vonNeumann = true
+			default:
+				err = fmt.Errorf("must be NM or NN")
+			}
+		default:
+			err = fmt.Errorf("unknown field")
+		}
+		if err != nil {
+			return LargerThanLifeRule{}, fmt.Errorf("rule %q: %s: %w", s, field, err)
+		}
+	}
+
+	if radius == 0 || r.birthMin < 0 || r.survivalMin < 0 {
+		return LargerThanLifeRule{}, fmt.Errorf("rule %q: R, S and B are required", s)
+	}
+	r.neighborhood = Moore(radius)
+	if vonNeumann {
+		r.neighborhood = VonNeumann(radius)
+	}
+	r.decay = GenerationsRule{states: r.states}
+
+	neighborhoodName := "NM"
+	if vonNeumann {
+		neighborhoodName = "NN"
+	}
+	middle := 0
+	if r.middle {
+		middle = 1
+	}
+	r.name = fmt.Sprintf("R%d,C%d,M%d,S%d..%d,B%d..%d,%s",
+		radius, r.states, middle, r.survivalMin, r.survivalMax, r.birthMin, r.birthMax, neighborhoodName)
+	return r, nil
+}
+
+// MustParseLargerThanLifeRule is ParseLargerThanLifeRule for rules known at compile time.
+func MustParseLargerThanLifeRule(s string) LargerThanLifeRule {
+	r, err := ParseLargerThanLifeRule(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// parseRange reads "34..58", or a single count such as "3"
+func parseRange(s string) (int, int, error) {
+	low, high, isRange := strings.Cut(s, "..")
+	if !isRange {
+		high = low
+	}
+	from, err := strconv.Atoi(low)
+	if err != nil || from < 0 {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	to, err := strconv.Atoi(high)
+	if err != nil || to < from {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	return from, to, nil
+}
+
+func (r LargerThanLifeRule) String() string {
+	return r.name
+}
+
+// Neighborhood is the range-R neighborhood the rule is defined over. Worlds
+// running the rule must be linked with it.
+func (r LargerThanLifeRule) Neighborhood() Neighborhood {
+	return r.neighborhood
+}
+
+func (r LargerThanLifeRule) Next(state uint8, neighbors []uint8) (uint8, string) {
+	alive := r.Alive()
+	if state != 0 && state != alive {
+		return state - 1, "Pining for the Fjords"
+	}
+
+	count := 0
+	for _, n := range neighbors {
+		if n == alive {
+			count++
+		}
+	}
+	if r.middle && state == alive {
+		count++
+	}
+
+	if state == alive {
+		if count >= r.survivalMin && count <= r.survivalMax {
+			return alive, "Porridge Just Right"
+		}
+		return alive - 1, "Under or Over Population"
+	}
+	if count >= r.birthMin && count <= r.birthMax {
+		return alive, "Nobody Expects the Cellular Resurrection"
+	}
+	return 0, "Still Mostly Dead"
+}
+
+func (r LargerThanLifeRule) Alive() uint8 {
+	return r.states - 1
+}
+
+func (r LargerThanLifeRule) Spawn(rng *rand.Rand) uint8 {
+	return r.Alive()
+}
+
+func (r LargerThanLifeRule) Glyph(state uint8) string {
+	return r.decay.Glyph(state)
+}
+
+func (r LargerThanLifeRule) Palette() renderer.Palette {
+	return r.decay.Palette()
+}
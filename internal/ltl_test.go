@@ -0,0 +1,93 @@
+package internal
+
+import "testing"
+
+func TestParseLargerThanLifeRule(t *testing.T) {
+	tests := []struct {
+		rule      string
+		want      string
+		neighbors int
+	}{
+		{rule: "R5,C0,M1,S34..58,B34..45,NM", want: "R5,C2,M1,S34..58,B34..45,NM", neighbors: 120},
+		{rule: "r5,c0,m1,s34..58,b34..45,nm", want: "R5,C2,M1,S34..58,B34..45,NM", neighbors: 120},
+		{rule: "R1,S2..3,B3", want: "R1,C2,M0,S2..3,B3..3,NM", neighbors: 8},
+		{rule: "B3,S2..3,R1,M0", want: "R1,C2,M0,S2..3,B3..3,NM", neighbors: 8},
+		{rule: "R2,C4,S3..5,B4,NN", want: "R2,C4,M0,S3..5,B4..4,NN", neighbors: 12},
+		{rule: "R3,C2,S0..10,B0..0", want: "R3,C2,M0,S0..10,B0..0,NM", neighbors: 48},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseLargerThanLifeRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.String() != tt.want {
+				t.Errorf("parsed as %q, want %q", r, tt.want)
+			}
+			if n := len(r.Neighborhood().offsets(0)); n != tt.neighbors {
+				t.Errorf("%d neighbors, want %d", n, tt.neighbors)
+			}
+		})
+	}
+}
+
+func TestParseLargerThanLifeRuleRejects(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"R5",
+		"R5,S34..58",
+		"R5,B34..45",
+		"S34..58,B34..45",
+		"R0,S1,B1",
+		"Rx,S1,B1",
+		"R5,,S1,B1",
+		"R5,S58..34,B1",
+		"R5,S-1,B1",
+		"R5,S1..x,B1",
+		"R5,C1,S1,B1",
+		"R5,C64,S1,B1",
+		"R5,M2,S1,B1",
+		"R5,S1,B1,NX",
+		"R5,S1,B1,X3",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			if r, err := ParseLargerThanLifeRule(rule); err == nil {
+				t.Errorf("parsed as %q, want an error", r)
+			}
+		})
+	}
+}
+
+func TestBoscoRanges(t *testing.T) {
+	alive := Bosco.Alive()
+	// count live neighbors out of Bosco's 120
+	count := func(live int) []uint8 {
+		neighbors := make([]uint8, 120)
+		for i := 0; i < live; i++ {
+			neighbors[i] = alive
+		}
+		return neighbors
+	}
+
+	tests := []struct {
+		name  string
+		state uint8
+		live  int
+		want  uint8
+	}{
+		{name: "born at the bottom of the birth range", state: 0, live: 34, want: alive},
+		{name: "born at the top of the birth range", state: 0, live: 45, want: alive},
+		{name: "not born above the birth range", state: 0, live: 46, want: 0},
+		{name: "survives counting itself", state: alive, live: 33, want: alive},
+		{name: "dies below the survival range", state: alive, live: 32, want: 0},
+		{name: "survives at the top of the survival range", state: alive, live: 57, want: alive},
+		{name: "dies above the survival range", state: alive, live: 58, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := Bosco.Next(tt.state, count(tt.live)); got != tt.want {
+				t.Errorf("Next(%d) with %d live neighbors = %d, want %d", tt.state, tt.live, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"os"
+	"testing"
+
+	glog "github.com/ninjapanzer/gogol_channels/log"
+)
+
+// TestMain keeps the app.log every cell writes to out of the source tree
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "gol-test")
+	if err != nil {
+		panic(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	closeLog := glog.InitLogger()
+
+	code := m.Run()
+
+	closeLog()
+	os.Chdir(wd)
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// grid turns rows of '#' and '.' into a pattern of live and dead cells
+func grid(rows ...string) [][]bool {
+	pattern := make([][]bool, len(rows))
+	for y, row := range rows {
+		pattern[y] = make([]bool, len(row))
+		for x, c := range row {
+			pattern[y][x] = c == '#'
+		}
+	}
+	return pattern
+}
+
+// render draws live cells as '#' and dead ones as '.', one string per row
+func render[C interface{ State() bool }](cells [][]C) []string {
+	rows := make([]string, len(cells))
+	for y, row := range cells {
+		line := make([]byte, len(row))
+		for x, cell := range row {
+			line[x] = '.'
+			if cell.State() {
+				line[x] = '#'
+			}
+		}
+		rows[y] = string(line)
+	}
+	return rows
+}
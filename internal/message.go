@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+// Location is the (y, x) position of a cell on the grid
+type Location struct {
+	Y, X int
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%d-%d", l.Y, l.X)
+}
+
+// CellMessage is what a cell broadcasts to its neighbors. Generation counts the
+// read cycles the sender has completed, which lets the synchronous mode hold a
+// cell back until every neighbor has reached the same generation. Seq numbers
+// every message a sender puts out, heartbeats included, so a receiver can
+// discard anything that overtook a newer message, and SentAt lets it measure
+// how long the message spent in flight. Lamport and, when enabled, Vector are
+// the sender's causal clocks at the time of sending. Vector is never modified
+// once sent, so every receiver can read it.
+type CellMessage[S comparable] struct {
+	Sender     Location
+	Generation uint64
+	Seq        uint64
+	SentAt     time.Time
+	Lamport    uint64
+	Vector     map[Location]uint64
+	State      S
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestApplyNoise(t *testing.T) {
+	tests := []struct {
+		name      string
+		noise     Noise
+		alive     bool
+		neighbors []bool
+		want      bool
+		event     string
+	}{
+		{name: "no noise applies the rule", noise: NoNoise, neighbors: neighbors(0, 1, 2), want: true},
+		{name: "suppressed birth", noise: Noise{Transition: 0}, neighbors: neighbors(0, 1, 2), want: false, event: Suppressed},
+		{name: "suppressed death", noise: Noise{Transition: 0}, alive: true, want: true, event: Suppressed},
+		{name: "no transition to suppress", noise: Noise{Transition: 0}, alive: true, neighbors: neighbors(0, 1), want: true},
+		{name: "spontaneous birth", noise: Noise{Transition: 1, Birth: 1}, want: true, event: NoiseBirth},
+		{name: "birth noise spares live cells", noise: Noise{Transition: 1, Birth: 1}, alive: true, neighbors: neighbors(0, 1), want: true},
+		{name: "spontaneous death", noise: Noise{Transition: 1, Death: 1}, alive: true, neighbors: neighbors(0, 1), want: false, event: NoiseDeath},
+		{name: "death noise spares dead cells", noise: Noise{Transition: 1, Death: 1}, want: false},
+		{name: "suppressed death then struck by lightning", noise: Noise{Transition: 0, Death: 1}, alive: true, want: false, event: NoiseDeath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChannelCell(tt.alive, Location{}, Rule[bool](Conway))
+			c.SetNoise(tt.noise, rand.New(rand.NewSource(1)))
+			c.neighborStates = tt.neighbors
+			events := make([]string, 0)
+			c.SetStatsFunc(func(event CellEvent) {
+				switch event.name {
+				case Suppressed, NoiseBirth, NoiseDeath:
+					events = append(events, event.name)
+				}
+			})
+
+			got, _ := c.computeStateFromNeighbors()
+			if got != tt.want {
+				t.Errorf("state %v, want %v", got, tt.want)
+			}
+			if tt.event == "" && len(events) > 0 {
+				t.Errorf("events %v, want none", events)
+			}
+			if tt.event != "" && (len(events) == 0 || events[len(events)-1] != tt.event) {
+				t.Errorf("events %v, want %s last", events, tt.event)
+			}
+		})
+	}
+}
+
+func TestNewNoiseRejects(t *testing.T) {
+	tests := []struct {
+		name                     string
+		transition, birth, death float64
+	}{
+		{name: "transition above one", transition: 1.5},
+		{name: "negative transition", transition: -0.1},
+		{name: "negative birth", transition: 1, birth: -0.1},
+		{name: "death above one", transition: 1, death: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if noise, err := NewNoise(tt.transition, tt.birth, tt.death); err == nil {
+				t.Errorf("accepted %+v, want an error", noise)
+			}
+		})
+	}
+}
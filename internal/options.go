@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+// Mode selects how cells in a ChannelWorld decide when to advance
+type Mode int
+
+const (
+	// Async lets every cell read and broadcast on its own clock
+	Async Mode = iota
+	// Sync holds each cell at a generation barrier until all of its neighbors catch up
+	Sync
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "async":
+		return Async, nil
+	case "sync":
+		return Sync, nil
+	}
+	return Async, fmt.Errorf("unknown mode %q (expected async or sync)", s)
+}
+
+func (m Mode) String() string {
+	if m == Sync {
+		return "sync"
+	}
+	return "async"
+}
+
+// WorldOption configures optional behaviour of a ChannelWorld
+type WorldOption func(*worldOptions)
+
+type worldOptions struct {
+	mode         Mode
+	seed         int64
+	boundary     Boundary
+	neighborhood Neighborhood
+	noise        Noise
+	clock        Clock
+	maxStaleness time.Duration
+	causality    Causality
+	backpressure Backpressure
+	links        LinkFaults
+	partitions   []Partition
+}
+
+func defaultWorldOptions() worldOptions {
+	return worldOptions{
+		mode:         Async,
+		seed:         time.Now().UnixNano(),
+		boundary:     DeadBoundary,
+		neighborhood: Moore(1),
+		noise:        NoNoise,
+		clock:        GlobalClock,
+		backpressure: DefaultBackpressure,
+	}
+}
+
+// Noise makes cells unreliable. Every read cycle a cell only applies the
+// transition its rule computed with probability Transition, and afterwards a
+// dead cell is spontaneously born with probability Birth or a live cell dies
+// with probability Death.
+type Noise struct {
+	Transition float64
+	Birth      float64
+	Death      float64
+}
+
+// NoNoise always applies the rule and never injects births or deaths
+var NoNoise = Noise{Transition: 1}
+
+// NewNoise checks that every probability lies between 0 and 1
+func NewNoise(transition, birth, death float64) (Noise, error) {
+	for _, p := range []struct {
+		name  string
+		value float64
+	}{{"transition probability", transition}, {"birth noise", birth}, {"death noise", death}} {
+		if p.value < 0 || p.value > 1 {
+			return NoNoise, fmt.Errorf("%s %v must be between 0 and 1", p.name, p.value)
+		}
+	}
+	return Noise{Transition: transition, Birth: birth, Death: death}, nil
+}
+
+// Enabled reports whether the noise changes anything at all
+func (n Noise) Enabled() bool {
+	return n != NoNoise
+}
+
+// WithMode selects asynchronous or generation-synchronous evolution
+func WithMode(mode Mode) WorldOption {
+	return func(o *worldOptions) {
+		o.mode = mode
+	}
+}
+
+// WithSeed fixes the seed used to scatter the initial population so a run can
+// be reproduced or compared against a SequentialWorld seeded the same way
+func WithSeed(seed int64) WorldOption {
+	return func(o *worldOptions) {
+		o.seed = seed
+	}
+}
+
+// WithBoundary selects how cells on the edge of the grid are wired
+func WithBoundary(boundary Boundary) WorldOption {
+	return func(o *worldOptions) {
+		o.boundary = boundary
+	}
+}
+
+// WithNeighborhood selects which surrounding cells each cell listens to
+func WithNeighborhood(neighborhood Neighborhood) WorldOption {
+	return func(o *worldOptions) {
+		o.neighborhood = neighborhood
+	}
+}
+
+// WithNoise makes every cell apply its rule stochastically and inject random
+// births and deaths. Each cell draws from its own generator seeded from the
+// world seed, so noisy runs can be reproduced as well.
+func WithNoise(noise Noise) WorldOption {
+	return func(o *worldOptions) {
+		o.noise = noise
+	}
+}
+
+// WithClock gives every cell its own read and broadcast periods drawn from
+// clock, seeded from the world seed like the noise generators
+func WithClock(clock Clock) WorldOption {
+	return func(o *worldOptions) {
+		o.clock = clock
+	}
+}
+
+// WithMaxStaleness makes every cell ignore neighbor messages that spent longer
+// than maxStaleness in flight. Zero, the default, keeps every message.
+func WithMaxStaleness(maxStaleness time.Duration) WorldOption {
+	return func(o *worldOptions) {
+		o.maxStaleness = maxStaleness
+	}
+}
+
+// WithCausality logs every state change for `gol trace` and optionally keeps a
+// vector clock in every cell
+func WithCausality(causality Causality) WorldOption {
+	return func(o *worldOptions) {
+		o.causality = causality
+	}
+}
+
+// WithBackpressure sets the capacity of every broadcast channel and what a
+// cell does when one is full. Sync mode always blocks, since the generation
+// barrier needs every message to arrive.
+func WithBackpressure(backpressure Backpressure) WorldOption {
+	return func(o *worldOptions) {
+		o.backpressure = backpressure
+	}
+}
+
+// WithLinkFaults puts a link goroutine on every edge between two cells that
+// delays, drops, duplicates and reorders messages. Sync mode ignores loss and
+// reordering.
+func WithLinkFaults(faults LinkFaults) WorldOption {
+	return func(o *worldOptions) {
+		o.links = faults
+	}
+}
+
+// WithPartitions cuts the links crossing each partition while it is in force.
+// Async mode drops the messages, sync mode holds them until the partition heals.
+func WithPartitions(partitions []Partition) WorldOption {
+	return func(o *worldOptions) {
+		o.partitions = partitions
+	}
+}
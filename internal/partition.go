@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Partition cuts every link crossing a line through the world for a while.
+// A column split at 40 separates columns 0 to 39 from the rest, a row split
+// does the same for rows. Links that wrap around the edge cross the line too.
+type Partition struct {
+	Start    time.Duration
+	Duration time.Duration
+	Column   bool
+	Index    int
+}
+
+func (p Partition) String() string {
+	axis := "row"
+	if p.Column {
+		axis = "column"
+	}
+	return fmt.Sprintf("%s %d", axis, p.Index)
+}
+
+// check makes sure the partition splits a world of the given size in two
+func (p Partition) check(height, width int) error {
+	limit := height
+	if p.Column {
+		limit = width
+	}
+	if p.Index < 1 || p.Index >= limit {
+		return fmt.Errorf("partition at %s is outside the %dx%d world", p, width, height)
+	}
+	return nil
+}
+
+// cuts reports whether the link between two cells crosses the partition
+func (p Partition) cuts(from, to Location) bool {
+	if p.Column {
+		return (from.X < p.Index) != (to.X < p.Index)
+	}
+	return (from.Y < p.Index) != (to.Y < p.Index)
+}
+
+// ParseScenario reads one partition per line in the form
+//
+//	[at <start>] split <column|row> <index> for <duration>
+//
+// such as "at 5s split column 40 for 10s". Start times count from the moment
+// the world starts and default to 0. Blank lines and lines starting with '#'
+// are skipped.
+func ParseScenario(in io.Reader) ([]Partition, error) {
+	partitions := make([]Partition, 0)
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		p, err := parsePartition(strings.Fields(text))
+		if err != nil {
+			return nil, fmt.Errorf("scenario line %d: %w", line, err)
+		}
+		partitions = append(partitions, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return partitions, nil
+}
+
+func parsePartition(fields []string) (Partition, error) {
+	p := Partition{}
+	if len(fields) > 0 && fields[0] == "at" {
+		if len(fields) < 2 {
+			return p, fmt.Errorf("expected a start time after at")
+		}
+		start, err := time.ParseDuration(fields[1])
+		if err != nil || start < 0 {
+			return p, fmt.Errorf("invalid start time %q", fields[1])
+		}
+		p.Start = start
+		fields = fields[2:]
+	}
+
+	if len(fields) != 5 || fields[0] != "split" || fields[3] != "for" {
+		return p, fmt.Errorf("expected split <column|row> <index> for <duration>")
+	}
+	switch fields[1] {
+	case "column":
+		p.Column = true
+	case "row":
+	default:
+		return p, fmt.Errorf("unknown axis %q (expected column or row)", fields[1])
+	}
+	index, err := strconv.Atoi(fields[2])
+	if err != nil || index < 1 {
+		return p, fmt.Errorf("invalid index %q", fields[2])
+	}
+	p.Index = index
+	duration, err := time.ParseDuration(fields[4])
+	if err != nil || duration <= 0 {
+		return p, fmt.Errorf("invalid duration %q", fields[4])
+	}
+	p.Duration = duration
+	return p, nil
+}
+
+// scheduledPartition broadcasts the start and end of a partition to every
+// link it cuts by closing started and then healed
+type scheduledPartition struct {
+	Partition
+	started chan struct{}
+	healed  chan struct{}
+}
+
+// active reports whether the partition has started and not yet healed
+func (p *scheduledPartition) active() bool {
+	select {
+	case <-p.healed:
+		return false
+	default:
+	}
+	select {
+	case <-p.started:
+		return true
+	default:
+		return false
+	}
+}
+
+// partitionSchedule starts and heals the partitions of a scenario on time
+type partitionSchedule struct {
+	partitions []*scheduledPartition
+	stats      *Stats
+}
+
+func newPartitionSchedule(partitions []Partition, stats *Stats) *partitionSchedule {
+	s := &partitionSchedule{stats: stats}
+	for _, p := range partitions {
+		s.partitions = append(s.partitions, &scheduledPartition{
+			Partition: p,
+			started:   make(chan struct{}),
+			healed:    make(chan struct{}),
+		})
+	}
+	return s
+}
+
+// cutting lists the partitions that cut the link between two cells
+func (s *partitionSchedule) cutting(from, to Location) []*scheduledPartition {
+	cutting := make([]*scheduledPartition, 0)
+	for _, p := range s.partitions {
+		if p.cuts(from, to) {
+			cutting = append(cutting, p)
+		}
+	}
+	return cutting
+}
+
+// run opens and heals every partition at its time until ctx is cancelled
+func (s *partitionSchedule) run(ctx context.Context) {
+	type change struct {
+		at        time.Duration
+		partition *scheduledPartition
+		heal      bool
+	}
+	changes := make([]change, 0, len(s.partitions)*2)
+	for _, p := range s.partitions {
+		changes = append(changes, change{at: p.Start, partition: p}, change{at: p.Start + p.Duration, partition: p, heal: true})
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].at < changes[j].at })
+
+	begin := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for _, c := range changes {
+		timer.Reset(time.Until(begin.Add(c.at)))
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if c.heal {
+			close(c.partition.healed)
+		} else {
+			close(c.partition.started)
+		}
+		s.stats.SetPartitions(s.describeActive())
+	}
+}
+
+// describeActive names the partitions currently in force for the stats window
+func (s *partitionSchedule) describeActive() []string {
+	active := make([]string, 0)
+	for _, p := range s.partitions {
+		if p.active() {
+			active = append(active, p.String())
+		}
+	}
+	return active
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ninjapanzer/gogol_channels/renderer/mock"
+)
+
+func TestParseScenario(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		want     []Partition
+	}{
+		{
+			name:     "start time",
+			scenario: "at 5s split column 40 for 10s",
+			want:     []Partition{{Start: 5 * time.Second, Duration: 10 * time.Second, Column: true, Index: 40}},
+		},
+		{
+			name:     "starts right away",
+			scenario: "split row 20 for 500ms",
+			want:     []Partition{{Duration: 500 * time.Millisecond, Index: 20}},
+		},
+		{
+			name:     "comments, blank lines and overlaps",
+			scenario: "# split the world\n\n  split column 40 for 10s  \n# then the top\nat 1m split row 20 for 5s\nat 2s split column 10 for 1m\n",
+			want: []Partition{
+				{Duration: 10 * time.Second, Column: true, Index: 40},
+				{Start: time.Minute, Duration: 5 * time.Second, Index: 20},
+				{Start: 2 * time.Second, Duration: time.Minute, Column: true, Index: 10},
+			},
+		},
+		{
+			name:     "empty",
+			scenario: "# nothing yet\n",
+			want:     []Partition{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScenario(strings.NewReader(tt.scenario))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScenarioRejects(t *testing.T) {
+	tests := []struct {
+		scenario string
+		err      string
+	}{
+		{scenario: "split column 40", err: "line 1: expected split"},
+		{scenario: "split column 40 for 10s now", err: "line 1: expected split"},
+		{scenario: "cut column 40 for 10s", err: "line 1: expected split"},
+		{scenario: "split column 40 during 10s", err: "line 1: expected split"},
+		{scenario: "split diagonal 40 for 10s", err: "line 1: unknown axis"},
+		{scenario: "split column x for 10s", err: "line 1: invalid index"},
+		{scenario: "split column 0 for 10s", err: "line 1: invalid index"},
+		{scenario: "split column 40 for 10", err: "line 1: invalid duration"},
+		{scenario: "split column 40 for 0s", err: "line 1: invalid duration"},
+		{scenario: "at", err: "line 1: expected a start time"},
+		{scenario: "at soon split row 5 for 1s", err: "line 1: invalid start time"},
+		{scenario: "at -1s split row 5 for 1s", err: "line 1: invalid start time"},
+		{scenario: "# fine\nsplit row 5 for 1s\nsplit row 5", err: "line 3: expected split"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			_, err := ParseScenario(strings.NewReader(tt.scenario))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPartitionCuts(t *testing.T) {
+	column := Partition{Column: true, Index: 5}
+	row := Partition{Index: 3}
+	tests := []struct {
+		name      string
+		partition Partition
+		from, to  Location
+		want      bool
+	}{
+		{name: "across the column", partition: column, from: Location{Y: 2, X: 4}, to: Location{Y: 2, X: 5}, want: true},
+		{name: "diagonally across the column", partition: column, from: Location{Y: 3, X: 5}, to: Location{Y: 2, X: 4}, want: true},
+		{name: "left of the column", partition: column, from: Location{Y: 2, X: 3}, to: Location{Y: 2, X: 4}, want: false},
+		{name: "right of the column", partition: column, from: Location{Y: 2, X: 5}, to: Location{Y: 2, X: 6}, want: false},
+		{name: "wrapping around the edge", partition: column, from: Location{Y: 2, X: 0}, to: Location{Y: 2, X: 9}, want: true},
+		{name: "along the column", partition: column, from: Location{Y: 2, X: 5}, to: Location{Y: 3, X: 5}, want: false},
+		{name: "across the row", partition: row, from: Location{Y: 2, X: 7}, to: Location{Y: 3, X: 7}, want: true},
+		{name: "above the row", partition: row, from: Location{Y: 1, X: 7}, to: Location{Y: 2, X: 8}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.partition.cuts(tt.from, tt.to); got != tt.want {
+				t.Errorf("cuts(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelWorldRejectsPartitionsOutsideTheWorld(t *testing.T) {
+	// The mock renderer is 10x10
+	tests := []Partition{
+		{Duration: time.Second, Column: true, Index: 10},
+		{Duration: time.Second, Column: true, Index: 0},
+		{Duration: time.Second, Index: 25},
+	}
+	for _, p := range tests {
+		t.Run(p.String(), func(t *testing.T) {
+			w := NewChannelWorld[bool](mock.NewMockRenderer(), 0.4, Conway, WithPartitions([]Partition{p}))
+			if err := w.Bootstrap(context.Background()); err == nil {
+				w.Shutdown()
+				t.Errorf("Bootstrap accepted %v", p)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strings"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// Rule decides how a cell holding a state of type S evolves and how that state
+// is drawn. The zero value of S is always the dead or quiescent state.
+type Rule[S comparable] interface {
+	// Next returns the state a cell moves to given its own state and the last
+	// known state of every neighbor in link order, along with a reason for logging
+	Next(state S, neighbors []S) (S, string)
+	// Alive is the canonical live state, used for fixed live borders
+	Alive() S
+	// Spawn picks a live state for a randomly seeded or painted cell
+	Spawn(rng *rand.Rand) S
+	// Glyph names a state. The shell renderer prints it and both renderers
+	// look it up in Palette to pick a color.
+	Glyph(state S) string
+	Palette() renderer.Palette
+	String() string
+}
+
+// Toolbox is implemented by rules with states worth painting one by one,
+// keyed by the mouse tool that paints each of them
+type Toolbox[S comparable] interface {
+	Tools() map[renderer.Key]S
+}
+
+// Speciated is implemented by rules whose live states are distinct species.
+// Worlds running such a rule keep a population count for each of them.
+type Speciated[S comparable] interface {
+	Species() []S
+}
+
+// Valued is implemented by rules with continuous states. Renderers draw such
+// states as a field of values between 0 and 1 instead of as glyphs.
+type Valued[S comparable] interface {
+	Value(state S) float64
+}
+
+// LifeRule is an outer-totalistic Life-like rule written in B/S notation.
+// Birth and survival are bitmasks indexed by the number of live neighbors,
+// so B36/S23 sets bits 3 and 6 of birth and bits 2 and 3 of survival.
+type LifeRule struct {
+	name     string
+	birth    uint32
+	survival uint32
+}
+
+// Conway is the classic B3/S23 rule and the default for every world.
+var Conway = MustParseRule("B3/S23")
+
+var _ Rule[bool] = LifeRule{}
+
+// ParseRule reads a rule in "B36/S23" notation. The older survival-first
+// "23/36" form is accepted as well since most pattern collections use it.
+func ParseRule(s string) (LifeRule, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 {
+		return LifeRule{}, fmt.Errorf("rule %q: expected two parts separated by '/'", s)
+	}
+
+	var bPart, sPart string
+	upper0, upper1 := strings.ToUpper(parts[0]), strings.ToUpper(parts[1])
+	switch {
+	case strings.HasPrefix(upper0, "B") && strings.HasPrefix(upper1, "S"):
+		bPart, sPart = upper0[1:], upper1[1:]
+	case strings.HasPrefix(upper0, "S") && strings.HasPrefix(upper1, "B"):
+		bPart, sPart = upper1[1:], upper0[1:]
+	default:
+		// Survival/Birth without prefixes
+		bPart, sPart = upper1, upper0
+	}
+
+	birth, err := parseCounts(bPart)
+	if err != nil {
+		return LifeRule{}, fmt.Errorf("rule %q: birth: %w", s, err)
+	}
+	survival, err := parseCounts(sPart)
+	if err != nil {
+		return LifeRule{}, fmt.Errorf("rule %q: survival: %w", s, err)
+	}
+
+	r := LifeRule{birth: birth, survival: survival}
+	r.name = "B" + r.digits(birth) + "/S" + r.digits(survival)
+	return r, nil
+}
+
+// MustParseRule is ParseRule for rules known at compile time.
+func MustParseRule(s string) LifeRule {
+	r, err := ParseRule(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func parseCounts(s string) (uint32, error) {
+	var mask uint32
+	for _, ch := range s {
+		if ch < '0' || ch > '8' {
+			return 0, fmt.Errorf("invalid neighbor count %q", ch)
+		}
+		mask |= 1 << uint(ch-'0')
+	}
+	return mask, nil
+}
+
+func (r LifeRule) digits(mask uint32) string {
+	var sb strings.Builder
+	for n := 0; n <= 8; n++ {
+		if mask&(1<<uint(n)) != 0 {
+			sb.WriteByte(byte('0' + n))
+		}
+	}
+	return sb.String()
+}
+
+func (r LifeRule) String() string {
+	return r.name
+}
+
+func (r LifeRule) Next(alive bool, neighbors []bool) (bool, string) {
+	return r.next(alive, countAlive(neighbors))
+}
+
+// next applies the rule to a neighbor count rather than individual neighbors
+func (r LifeRule) next(alive bool, aliveCount int) (bool, string) {
+	if alive {
+		if r.has(r.survival, aliveCount) {
+			return true, "Porridge Just Right"
+		}
+		return false, "Under or Over Population"
+	}
+	if r.has(r.birth, aliveCount) {
+		return true, "Nobody Expects the Cellular Resurrection"
+	}
+	return false, "Still Mostly Dead"
+}
+
+func (r LifeRule) has(mask uint32, n int) bool {
+	return n >= 0 && n < 32 && mask&(1<<uint(n)) != 0
+}
+
+func (r LifeRule) Alive() bool {
+	return true
+}
+
+func (r LifeRule) Spawn(rng *rand.Rand) bool {
+	return true
+}
+
+func (r LifeRule) Glyph(alive bool) string {
+	if alive {
+		return renderer.AliveGlyph
+	}
+	return renderer.DeadGlyph
+}
+
+func (r LifeRule) Palette() renderer.Palette {
+	return renderer.Palette{
+		renderer.AliveGlyph: {Color: color.RGBA{240, 240, 240, 255}},
+	}
+}
+
+// isDead reports whether state is the zero value every rule treats as dead
+func isDead[S comparable](state S) bool {
+	var dead S
+	return state == dead
+}
+
+// countAlive counts the neighbors holding anything other than the dead zero value
+func countAlive[S comparable](neighbors []S) int {
+	count := 0
+	for _, n := range neighbors {
+		if !isDead(n) {
+			count++
+		}
+	}
+	return count
+}
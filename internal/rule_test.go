@@ -0,0 +1,75 @@
+package internal
+
+import "testing"
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{rule: "B3/S23", want: "B3/S23"},
+		{rule: "b36/s23", want: "B36/S23"},
+		{rule: "S23/B3", want: "B3/S23"},
+		{rule: "23/3", want: "B3/S23"},
+		{rule: "23/36", want: "B36/S23"},
+		{rule: " B2/S ", want: "B2/S"},
+		{rule: "B/S012345678", want: "B/S012345678"},
+		{rule: "B63/S32", want: "B36/S23"},
+		{rule: "B33/S2", want: "B3/S2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.String() != tt.want {
+				t.Errorf("parsed as %q, want %q", r, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRuleRejects(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"B3",
+		"B3/S23/C3",
+		"B9/S23",
+		"B3/S2x",
+		"B3/S-1",
+		"Bx/S23",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			if r, err := ParseRule(rule); err == nil {
+				t.Errorf("parsed as %q, want an error", r)
+			}
+		})
+	}
+}
+
+func TestLifeRuleNext(t *testing.T) {
+	highLife := MustParseRule("B36/S23")
+	tests := []struct {
+		name      string
+		rule      LifeRule
+		alive     bool
+		neighbors []bool
+		want      bool
+	}{
+		{name: "dead cell with three neighbors is born", rule: Conway, neighbors: neighbors(0, 1, 2), want: true},
+		{name: "dead cell with two neighbors stays dead", rule: Conway, neighbors: neighbors(0, 1), want: false},
+		{name: "live cell with two neighbors survives", rule: Conway, alive: true, neighbors: neighbors(0, 1), want: true},
+		{name: "live cell with four neighbors dies", rule: Conway, alive: true, neighbors: neighbors(0, 1, 2, 3), want: false},
+		{name: "live cell alone dies", rule: Conway, alive: true, neighbors: neighbors(), want: false},
+		{name: "six neighbors give birth in HighLife", rule: highLife, neighbors: neighbors(0, 1, 2, 3, 4, 5), want: true},
+		{name: "six neighbors do not give birth in Life", rule: Conway, neighbors: neighbors(0, 1, 2, 3, 4, 5), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := tt.rule.Next(tt.alive, tt.neighbors); got != tt.want {
+				t.Errorf("%v Next(%v) = %v, want %v", tt.rule, tt.alive, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package internal
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/ninjapanzer/gogol_channels/game"
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// SequentialCell is a plain value cell. It has no goroutines or channels and
+// only changes when its SequentialWorld computes a new generation.
+type SequentialCell[S comparable] struct {
+	game.Life[S]
+	state S
+}
+
+func (c *SequentialCell[S]) State() S {
+	return c.state
+}
+
+func (c *SequentialCell[S]) SetState(state S) {
+	c.state = state
+}
+
+// SequentialWorld is the textbook array backed implementation of Life. Every
+// call to ComputeState advances the whole grid by exactly one generation, which
+// makes it the reference the channel world is measured against and a cheap
+// backend when nothing needs to be concurrent.
+type SequentialWorld[S comparable] struct {
+	r            renderer.Renderer
+	cells        [][]*SequentialCell[S]
+	next         [][]S
+	neighbors    []S
+	rule         Rule[S]
+	initProb     float64
+	pattern      [][]S
+	seed         int64
+	boundary     Boundary
+	neighborhood Neighborhood
+	generation   uint64
+	control      chan cellCommand
+	paints       chan cellPaint[S]
+	// running is set while Run listens on control and paints, stopped is closed once it returns
+	running atomic.Bool
+	stopped chan struct{}
+	paused  atomic.Bool
+}
+
+var _ game.World[*SequentialCell[bool], bool] = (*SequentialWorld[bool])(nil)
+
+// cellPaint is a SetCell waiting for Run to apply it between generations
+type cellPaint[S comparable] struct {
+	y, x  int
+	state S
+}
+
+func NewSequentialWorld[S comparable](height, width int, prob float64, rule Rule[S]) *SequentialWorld[S] {
+	cells := make([][]*SequentialCell[S], height)
+	next := make([][]S, height)
+	for i := range cells {
+		cells[i] = make([]*SequentialCell[S], width)
+		next[i] = make([]S, width)
+		for j := range cells[i] {
+			cells[i][j] = &SequentialCell[S]{}
+		}
+	}
+	return &SequentialWorld[S]{
+		cells:        cells,
+		next:         next,
+		rule:         rule,
+		initProb:     prob,
+		seed:         time.Now().UnixNano(),
+		neighborhood: Moore(1),
+		control:      make(chan cellCommand, 4),
+		paints:       make(chan cellPaint[S], 64),
+		stopped:      make(chan struct{}),
+	}
+}
+
+// SetRenderer makes the world draw every generation. Without one it runs headless.
+func (w *SequentialWorld[S]) SetRenderer(r renderer.Renderer) {
+	w.r = r
+	w.r.SetLayout(layoutFor(w.neighborhood))
+	w.r.SetPalette(w.rule.Palette())
+}
+
+// SetSeed fixes the seed used by Bootstrap. A ChannelWorld of the same size
+// created WithSeed(seed) and the same probability starts from the same pattern.
+func (w *SequentialWorld[S]) SetSeed(seed int64) {
+	w.seed = seed
+}
+
+// SetPattern makes Bootstrap place pattern in the middle of the world instead of seeding it at random
+func (w *SequentialWorld[S]) SetPattern(pattern [][]S) {
+	w.pattern = pattern
+}
+
+// SetNeighborhood selects the neighbor shape, which must match the channel world it is compared with
+func (w *SequentialWorld[S]) SetNeighborhood(neighborhood Neighborhood) {
+	w.neighborhood = neighborhood
+	if w.r != nil {
+		w.r.SetLayout(layoutFor(neighborhood))
+	}
+}
+
+// SetBoundary selects the edge behaviour, which must match the channel world it is compared with
+func (w *SequentialWorld[S]) SetBoundary(boundary Boundary) {
+	w.boundary = boundary
+}
+
+func (w *SequentialWorld[S]) Cells() [][]*SequentialCell[S] {
+	return w.cells
+}
+
+func (w *SequentialWorld[S]) Generation() uint64 {
+	return w.generation
+}
+
+// Bootstrap seeds the grid. Unlike the channel world nothing starts running,
+// call ComputeState directly or hand the world to Run. It never fails.
+func (w *SequentialWorld[S]) Bootstrap(ctx context.Context) error {
+	rng := rand.New(rand.NewSource(w.seed))
+	for i := range w.cells {
+		for j := range w.cells[i] {
+			var state S
+			if w.pattern != nil {
+				state = patternState(w.pattern, i, j, len(w.cells), len(w.cells[i]))
+			} else if rng.Float64() < w.initProb {
+				state = w.rule.Spawn(rng)
+			}
+			w.cells[i][j].SetState(state)
+		}
+	}
+	w.DrawWorld()
+	return nil
+}
+
+// ComputeState advances the world by one generation
+func (w *SequentialWorld[S]) ComputeState() {
+	for y := range w.cells {
+		for x, cell := range w.cells[y] {
+			w.next[y][x], _ = w.rule.Next(cell.State(), w.neighborStates(y, x))
+		}
+	}
+
+	for y := range w.cells {
+		for x, cell := range w.cells[y] {
+			if cell.State() != w.next[y][x] {
+				cell.SetState(w.next[y][x])
+				w.drawCell(y, x)
+			}
+		}
+	}
+	w.generation++
+	if w.r != nil {
+		w.r.BufferUpdate()
+	}
+}
+
+// neighborStates lists the state of every neighbor of (y, x) in the same order
+// a channel cell links them. The slice is reused between calls.
+func (w *SequentialWorld[S]) neighborStates(y, x int) []S {
+	height := len(w.cells)
+	width := len(w.cells[0])
+	border := borderState(w.boundary, w.rule)
+
+	w.neighbors = w.neighbors[:0]
+	w.neighborhood.forEachNeighbor(w.boundary, y, x, height, width, func(ny, nx int, inside bool) {
+		if inside {
+			w.neighbors = append(w.neighbors, w.cells[ny][nx].State())
+		} else {
+			w.neighbors = append(w.neighbors, border)
+		}
+	})
+	return w.neighbors
+}
+
+// SetCell changes a single cell, ignoring coordinates outside the grid. While
+// Run is going the change is handed to it and lands between two generations.
+func (w *SequentialWorld[S]) SetCell(y, x int, state S) {
+	if y < 0 || y >= len(w.cells) || x < 0 || x >= len(w.cells[y]) {
+		return
+	}
+	p := cellPaint[S]{y: y, x: x, state: state}
+	if !w.running.Load() {
+		w.paint(p)
+		return
+	}
+	select {
+	case w.paints <- p:
+	case <-w.stopped:
+	}
+}
+
+// applyPaints applies every paint already queued for Run
+func (w *SequentialWorld[S]) applyPaints() {
+	for {
+		select {
+		case p := <-w.paints:
+			w.paint(p)
+		default:
+			return
+		}
+	}
+}
+
+func (w *SequentialWorld[S]) paint(p cellPaint[S]) {
+	w.cells[p.y][p.x].SetState(p.state)
+	w.drawCell(p.y, p.x)
+}
+
+// Run advances one generation every GlobalReadRate milliseconds until ctx is
+// cancelled, honouring Pause, Resume and Step. It may only be called once.
+func (w *SequentialWorld[S]) Run(ctx context.Context) {
+	timer := time.NewTimer(period(time.Duration(atomic.LoadInt64(&GlobalReadRate))))
+	defer timer.Stop()
+	w.running.Store(true)
+	defer close(w.stopped)
+	defer w.running.Store(false)
+
+	paused := w.paused.Load()
+	for {
+		var tick <-chan time.Time
+		if !paused {
+			tick = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case cmd := <-w.control:
+			// Paints sent before the command land first
+			w.applyPaints()
+			switch cmd {
+			case cmdPause:
+				paused = true
+			case cmdResume:
+				if paused {
+					paused = false
+					timer.Reset(period(time.Duration(atomic.LoadInt64(&GlobalReadRate))))
+				}
+			case cmdStep:
+				if paused {
+					w.ComputeState()
+				}
+			}
+			continue
+		case p := <-w.paints:
+			w.paint(p)
+			continue
+		case <-tick:
+		}
+
+		w.ComputeState()
+		timer.Reset(period(time.Duration(atomic.LoadInt64(&GlobalReadRate))))
+	}
+}
+
+func (w *SequentialWorld[S]) Pause() {
+	w.paused.Store(true)
+	w.command(cmdPause)
+}
+
+func (w *SequentialWorld[S]) Resume() {
+	w.paused.Store(false)
+	w.command(cmdResume)
+}
+
+func (w *SequentialWorld[S]) TogglePause() {
+	if w.paused.Load() {
+		w.Resume()
+	} else {
+		w.Pause()
+	}
+}
+
+// Step advances exactly one generation, pausing the world first if needed
+func (w *SequentialWorld[S]) Step() {
+	if !w.paused.Load() {
+		w.Pause()
+	}
+	w.command(cmdStep)
+}
+
+func (w *SequentialWorld[S]) Paused() bool {
+	return w.paused.Load()
+}
+
+// command hands cmd to Run, giving up once Run has returned. Without Run
+// listening a step is computed right away and pausing only sets the flag Run
+// starts from.
+func (w *SequentialWorld[S]) command(cmd cellCommand) {
+	if !w.running.Load() {
+		if cmd == cmdStep {
+			w.ComputeState()
+		}
+		return
+	}
+	select {
+	case w.control <- cmd:
+	case <-w.stopped:
+	}
+}
+
+func (w *SequentialWorld[S]) drawCell(y, x int) {
+	if w.r == nil {
+		return
+	}
+	drawState(w.r, w.rule, y, x, w.cells[y][x].State())
+}
+
+func (w *SequentialWorld[S]) DrawWorld() {
+	if w.r == nil {
+		return
+	}
+	for y, row := range w.cells {
+		for x := range row {
+			w.drawCell(y, x)
+		}
+	}
+	w.r.BufferUpdate()
+}
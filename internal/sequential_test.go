@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+	"github.com/ninjapanzer/gogol_channels/renderer/mock"
+)
+
+func TestSequentialWorldEvolvesPatterns(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     [][]bool
+		generations int
+		want        []string
+	}{
+		{
+			name:        "blinker turns vertical",
+			pattern:     grid("###"),
+			generations: 1,
+			want:        []string{"......", "..#...", "..#...", "..#...", "......", "......"},
+		},
+		{
+			name:        "blinker has period two",
+			pattern:     grid("###"),
+			generations: 2,
+			want:        []string{"......", "......", ".###..", "......", "......", "......"},
+		},
+		{
+			name:        "block is still",
+			pattern:     grid("##", "##"),
+			generations: 5,
+			want:        []string{"......", "......", "..##..", "..##..", "......", "......"},
+		},
+		{
+			name:        "glider moves diagonally every four generations",
+			pattern:     grid(".#.", "..#", "###"),
+			generations: 4,
+			want:        []string{"......", "......", "...#..", "....#.", "..###.", "......"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewSequentialWorld[bool](6, 6, 0, Conway)
+			w.SetPattern(tt.pattern)
+			if err := w.Bootstrap(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.generations; i++ {
+				w.ComputeState()
+			}
+
+			if got := render(w.Cells()); !slices.Equal(got, tt.want) {
+				t.Errorf("after %d generations got\n%v\nwant\n%v", tt.generations, got, tt.want)
+			}
+			if w.Generation() != uint64(tt.generations) {
+				t.Errorf("generation %d, want %d", w.Generation(), tt.generations)
+			}
+		})
+	}
+}
+
+func TestSequentialWorldCommandsNeverBlock(t *testing.T) {
+	w := NewSequentialWorld[bool](6, 6, 0, Conway)
+	w.SetPattern(grid("###"))
+	if err := w.Bootstrap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without Run every step is computed right away
+	for i := 0; i < 10; i++ {
+		w.Step()
+	}
+	if w.Generation() != 10 {
+		t.Fatalf("generation %d after 10 steps, want 10", w.Generation())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	finished := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			w.Resume()
+			w.Pause()
+			w.Step()
+		}
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("commands blocked after Run returned")
+	}
+}
+
+func TestSyncChannelWorldMatchesSequentialWorld(t *testing.T) {
+	// Cells only advance when stepped
+	readRate := atomic.SwapInt64(&GlobalReadRate, int64(time.Hour/time.Millisecond))
+	t.Cleanup(func() { atomic.StoreInt64(&GlobalReadRate, readRate) })
+
+	for _, boundary := range []Boundary{DeadBoundary, Torus} {
+		t.Run(boundary.String(), func(t *testing.T) {
+			channel := NewChannelWorld[bool](mock.NewMockRenderer(), 0.4, Conway, WithSeed(7), WithMode(Sync), WithBoundary(boundary))
+			height, width := mock.NewMockRenderer().Dimensions()
+			reference := NewSequentialWorld[bool](height, width, 0.4, Conway)
+			reference.SetSeed(7)
+			reference.SetBoundary(boundary)
+			if err := reference.Bootstrap(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := channel.Bootstrap(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			defer channel.Shutdown()
+			channel.Pause()
+
+			for generation := uint64(0); generation <= 20; generation++ {
+				if generation > 0 {
+					channel.Step()
+					reference.ComputeState()
+				}
+				awaitGeneration(t, channel, generation)
+				if got, want := render(channel.Cells()), render(reference.Cells()); !slices.Equal(got, want) {
+					t.Fatalf("generation %d got\n%v\nwant\n%v", generation, got, want)
+				}
+			}
+		})
+	}
+}
+
+// awaitGeneration waits for every cell of w to complete generation
+func awaitGeneration[S comparable](t *testing.T, w *ChannelWorld[S], generation uint64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, row := range w.Cells() {
+		for _, cell := range row {
+			for cell.Generation() < generation {
+				if time.Now().After(deadline) {
+					t.Fatalf("cell %s stuck at generation %d, want %d", cell.location, cell.Generation(), generation)
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+}
+
+func TestSequentialWorldPaintsWhileRunning(t *testing.T) {
+	r := &updateRenderer{Renderer: mock.NewMockRenderer(), updates: make(chan struct{}, 4)}
+	w := NewSequentialWorld[bool](6, 6, 0, Conway)
+	w.SetRenderer(r)
+	if err := w.Bootstrap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-r.updates
+	w.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	// Paints go to Run and land before the step that follows them
+	for x := 1; x <= 3; x++ {
+		w.SetCell(2, x, true)
+	}
+	w.Step()
+	select {
+	case <-r.updates:
+	case <-time.After(time.Second):
+		t.Fatal("step never computed")
+	}
+	cancel()
+	<-done
+
+	want := []string{"......", "..#...", "..#...", "..#...", "......", "......"}
+	if got := render(w.Cells()); !slices.Equal(got, want) {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+// updateRenderer reports every BufferUpdate, which a world makes once per generation
+type updateRenderer struct {
+	renderer.Renderer
+	updates chan struct{}
+}
+
+func (r *updateRenderer) BufferUpdate() {
+	r.updates <- struct{}{}
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strings"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// Species is the color of a live cell in a multi-species rule, 0 is dead
+type Species uint8
+
+// speciesStyles names and colors each species in order
+var speciesStyles = []struct {
+	glyph string
+	color color.RGBA
+}{
+	{"A", color.RGBA{255, 64, 64, 255}},
+	{"B", color.RGBA{64, 128, 255, 255}},
+	{"C", color.RGBA{64, 255, 64, 255}},
+	{"D", color.RGBA{255, 220, 0, 255}},
+}
+
+// SpeciesRule is a Life-like rule where every live cell belongs to one of
+// several species. Survivors keep their species and a newborn takes the
+// majority species of its live neighbors. Immigration has two species and
+// QuadLife four, where a birth from three parents of different species takes
+// the fourth species instead.
+type SpeciesRule struct {
+	name    string
+	life    LifeRule
+	species int
+}
+
+var _ Rule[Species] = SpeciesRule{}
+var _ Speciated[Species] = SpeciesRule{}
+
+var (
+	Immigration = SpeciesRule{name: "immigration", life: Conway, species: 2}
+	QuadLife    = SpeciesRule{name: "quadlife", life: Conway, species: 4}
+)
+
+// ParseSpeciesRule reads "immigration" or "quadlife", optionally followed by
+// ":" and a Life-like rule to use instead of B3/S23, e.g. "quadlife:B36/S23"
+func ParseSpeciesRule(s string) (SpeciesRule, error) {
+	name, lifePart, hasLife := strings.Cut(strings.TrimSpace(s), ":")
+
+	var r SpeciesRule
+	switch strings.ToLower(name) {
+	case "immigration":
+		r = Immigration
+	case "quadlife":
+		r = QuadLife
+	default:
+		return SpeciesRule{}, fmt.Errorf("unknown species rule %q (expected immigration or quadlife)", s)
+	}
+
+	if hasLife {
+		life, err := ParseRule(lifePart)
+		if err != nil {
+			return SpeciesRule{}, fmt.Errorf("rule %q: %w", s, err)
+		}
+		r.life = life
+		r.name = r.name + ":" + life.String()
+	}
+	return r, nil
+}
+
+func (r SpeciesRule) String() string {
+	return r.name
+}
+
+func (r SpeciesRule) Next(state Species, neighbors []Species) (Species, string) {
+	next, reason := r.life.next(state != 0, countAlive(neighbors))
+	switch {
+	case !next:
+		return 0, reason
+	case state != 0:
+		return state, reason
+	}
+	return r.newborn(neighbors), reason
+}
+
+// newborn picks the species of a cell being born. The species most common
+// among its live neighbors wins, ties going to the lowest numbered species.
+// With four species and three parents that are all different, the newborn
+// takes the species none of them have.
+func (r SpeciesRule) newborn(neighbors []Species) Species {
+	tally := make([]int, r.species+1)
+	parents := 0
+	for _, n := range neighbors {
+		if n != 0 {
+			tally[n]++
+			parents++
+		}
+	}
+
+	best := Species(1)
+	for s := 2; s <= r.species; s++ {
+		if tally[s] > tally[best] {
+			best = Species(s)
+		}
+	}
+
+	if r.species == 4 && parents == 3 && tally[best] == 1 {
+		for s := 1; s <= r.species; s++ {
+			if tally[s] == 0 {
+				return Species(s)
+			}
+		}
+	}
+	return best
+}
+
+func (r SpeciesRule) Alive() Species {
+	return 1
+}
+
+// Spawn picks a species at random
+func (r SpeciesRule) Spawn(rng *rand.Rand) Species {
+	return Species(1 + rng.Intn(r.species))
+}
+
+func (r SpeciesRule) Glyph(state Species) string {
+	if state == 0 || int(state) > r.species {
+		return renderer.DeadGlyph
+	}
+	return speciesStyles[state-1].glyph
+}
+
+func (r SpeciesRule) Palette() renderer.Palette {
+	palette := renderer.Palette{}
+	for _, style := range speciesStyles[:r.species] {
+		palette[style.glyph] = renderer.Style{Color: style.color}
+	}
+	return palette
+}
+
+// Species lists every live state
+func (r SpeciesRule) Species() []Species {
+	species := make([]Species, r.species)
+	for i := range species {
+		species[i] = Species(i + 1)
+	}
+	return species
+}
@@ -1,23 +1,44 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	glog "github.com/ninjapanzer/gogol_channels/log"
 	"github.com/ninjapanzer/gogol_channels/renderer"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	Heartbeat   = "heartbeat"
-	Broadcast   = "broadcast"
-	Died        = "died"
-	Resurrected = "resurrected"
+	Heartbeat        = "heartbeat"
+	Broadcast        = "broadcast"
+	Died             = "died"
+	Resurrected      = "resurrected"
+	Population       = "population"
+	NoiseBirth       = "noise-birth"
+	NoiseDeath       = "noise-death"
+	Suppressed       = "suppressed"
+	Latency          = "latency"
+	OutOfOrder       = "out-of-order"
+	Stale            = "stale"
+	Dropped          = "dropped"
+	Coalesced        = "coalesced"
+	LinkLost         = "link-lost"
+	LinkDuplicated   = "link-duplicated"
+	LinkReordered    = "link-reordered"
+	PartitionBlocked = "partition-blocked"
 )
 
 type CellEvent struct {
 	name  string
 	count int
+	// species is the glyph of the species a Population event counts
+	species string
+	// latency is the total and peak time in flight of the count messages a
+	// Latency event reports
+	latency time.Duration
+	peak    time.Duration
 }
 
 type Stats struct {
@@ -31,15 +52,39 @@ type Stats struct {
 	died               int64
 	diedPerSecond      int64
 	eventChan          chan CellEvent
+	done               <-chan struct{}
+	paused             atomic.Bool
+	divergence         atomic.Pointer[DivergenceSample]
+	noiseBirths        int64
+	noiseDeaths        int64
+	suppressed         int64
+	species            []string
+	population         map[string]int64
+	latencyAverage     time.Duration
+	latencyPeak        time.Duration
+	outOfOrder         int64
+	stale              int64
+	dropped            int64
+	coalesced          int64
+	linkLost           int64
+	linkDuplicated     int64
+	linkReordered      int64
+	partitions         atomic.Pointer[[]string]
+	partitionBlocked   int64
 }
 
+// statsHeight leaves room for the summary line plus the optional detail lines
+const statsHeight = 9
+
+// statsWidth is the approximate width of the stats window based on the text length
+const statsWidth = 80
+
 func NewStats(r renderer.Renderer, location string) *Stats {
 	_, x := r.Dimensions()
 	// Position the stats window in the top right with padding
 	padding := 20 // Padding from the right edge
 	// Use a smaller width for the stats window to make it fit the text better
-	statsWidth := 80 // Approximate width based on the text length
-	st := r.CreateStatsWindow(3, statsWidth, 1, x-statsWidth-padding)
+	st := r.CreateStatsWindow(statsHeight, statsWidth, 1, x-statsWidth-padding)
 
 	s := &Stats{
 		r:          r,
@@ -52,61 +97,134 @@ func NewStats(r renderer.Renderer, location string) *Stats {
 		eventChan:  make(chan CellEvent, 10000),
 	}
 
-	s.collectStats()
-
 	return s
 }
 
 func (s *Stats) AddEvent(event CellEvent) {
 	glog.GetLogger().Debug("AddEvent", "Event", event.name)
-	s.eventChan <- event
+	select {
+	case s.eventChan <- event:
+	case <-s.done:
+	}
 }
 
-func (s *Stats) collectStats() {
-	go func() {
-		ticker := time.NewTicker(time.Second)
-		defer ticker.Stop()
-
-		hps := 0
-		bps := 0
-		dps := 0
-		for {
-			select {
-			case <-ticker.C:
-				s.heartbeatPerSecond = int64(hps)
-				s.broadcastPerSecond = int64(bps)
-				s.diedPerSecond = int64(dps)
-				hps = 0
-				bps = 0
-				dps = 0
-			case e := <-s.eventChan:
-				if e.name == Heartbeat {
-					hps += e.count
-					s.heartbeats += int64(e.count)
-				} else if e.name == Broadcast {
-					bps += e.count
-					s.broadcasts += int64(e.count)
-				} else if e.name == Died {
-					dps += e.count
-					s.died += int64(e.count)
-				} else if e.name == Resurrected {
-					dps -= e.count
-					s.died -= int64(e.count)
-				}
-			default:
-			}
-		}
-	}()
-	go func() {
-		for {
-			time.Sleep(250 * time.Millisecond)
+// bind ties AddEvent to the lifetime of ctx so cells never block on a collector that has exited
+func (s *Stats) bind(ctx context.Context) {
+	s.done = ctx.Done()
+}
+
+// collectStats tallies cell events into running totals and per second rates
+// and redraws the stats window four times a second until ctx is cancelled.
+// Only this goroutine touches the totals, so drawing them needs no locking.
+func (s *Stats) collectStats(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	refresh := time.NewTicker(250 * time.Millisecond)
+	defer refresh.Stop()
+
+	hps := 0
+	bps := 0
+	dps := 0
+	latencyCount := 0
+	var latencyTotal, latencyPeak time.Duration
+	// Only this goroutine touches population, a copy is published every tick
+	population := make(map[string]int64)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-refresh.C:
 			s.Update()
+		case <-ticker.C:
+			s.heartbeatPerSecond = int64(hps)
+			s.broadcastPerSecond = int64(bps)
+			s.diedPerSecond = int64(dps)
+			snapshot := make(map[string]int64, len(population))
+			for species, count := range population {
+				snapshot[species] = count
+			}
+			s.population = snapshot
+			if latencyCount > 0 {
+				s.latencyAverage = latencyTotal / time.Duration(latencyCount)
+				s.latencyPeak = latencyPeak
+			}
+			latencyCount = 0
+			latencyTotal, latencyPeak = 0, 0
+			hps = 0
+			bps = 0
+			dps = 0
+		case e := <-s.eventChan:
+			if e.name == Heartbeat {
+				hps += e.count
+				s.heartbeats += int64(e.count)
+			} else if e.name == Broadcast {
+				bps += e.count
+				s.broadcasts += int64(e.count)
+			} else if e.name == Died {
+				dps += e.count
+				s.died += int64(e.count)
+			} else if e.name == Resurrected {
+				dps -= e.count
+				s.died -= int64(e.count)
+			} else if e.name == Population {
+				population[e.species] += int64(e.count)
+			} else if e.name == NoiseBirth {
+				s.noiseBirths += int64(e.count)
+			} else if e.name == NoiseDeath {
+				s.noiseDeaths += int64(e.count)
+			} else if e.name == Suppressed {
+				s.suppressed += int64(e.count)
+			} else if e.name == Latency {
+				latencyCount += e.count
+				latencyTotal += e.latency
+				latencyPeak = max(latencyPeak, e.peak)
+			} else if e.name == OutOfOrder {
+				s.outOfOrder += int64(e.count)
+			} else if e.name == Stale {
+				s.stale += int64(e.count)
+			} else if e.name == Dropped {
+				s.dropped += int64(e.count)
+			} else if e.name == Coalesced {
+				s.coalesced += int64(e.count)
+			} else if e.name == LinkLost {
+				s.linkLost += int64(e.count)
+			} else if e.name == LinkDuplicated {
+				s.linkDuplicated += int64(e.count)
+			} else if e.name == LinkReordered {
+				s.linkReordered += int64(e.count)
+			} else if e.name == PartitionBlocked {
+				s.partitionBlocked += int64(e.count)
+			}
 		}
-	}()
+	}
+}
+
+// SetPaused flags the stats line so it is obvious the world is frozen
+func (s *Stats) SetPaused(paused bool) {
+	s.paused.Store(paused)
+}
+
+// SetSpecies lists the glyphs of the species to show a population for, in order
+func (s *Stats) SetSpecies(species []string) {
+	s.species = species
+}
+
+// SetPartitions lists the partitions currently cutting the world
+func (s *Stats) SetPartitions(partitions []string) {
+	s.partitions.Store(&partitions)
+}
+
+// SetDivergence publishes the most recent async versus reference comparison
+func (s *Stats) SetDivergence(sample DivergenceSample) {
+	s.divergence.Store(&sample)
 }
 
 func (s *Stats) String() string {
-	return fmt.Sprintf(
+	status := ""
+	if s.paused.Load() {
+		status = "[Paused] "
+	}
+	return status + fmt.Sprintf(
 		"Died: %v "+
 			"d/s: %v "+
 			"Broadcasts: %v "+
@@ -121,12 +239,85 @@ func (s *Stats) String() string {
 		s.heartbeatPerSecond)
 }
 
+// lines returns the summary followed by any optional detail lines
+func (s *Stats) lines() []string {
+	lines := []string{s.String()}
+	if len(s.species) > 0 {
+		var sb strings.Builder
+		sb.WriteString("Population")
+		for _, species := range s.species {
+			fmt.Fprintf(&sb, " %v: %v", species, s.population[species])
+		}
+		lines = append(lines, sb.String())
+	}
+	if s.noiseBirths > 0 || s.noiseDeaths > 0 || s.suppressed > 0 {
+		lines = append(lines, fmt.Sprintf(
+			"Noise births: %v "+
+				"Noise deaths: %v "+
+				"Suppressed: %v",
+			s.noiseBirths,
+			s.noiseDeaths,
+			s.suppressed))
+	}
+	if s.latencyAverage > 0 {
+		lines = append(lines, fmt.Sprintf(
+			"Latency avg: %v "+
+				"max: %v "+
+				"Out of order: %v "+
+				"Stale: %v",
+			s.latencyAverage.Round(time.Microsecond),
+			s.latencyPeak.Round(time.Microsecond),
+			s.outOfOrder,
+			s.stale))
+	}
+	if s.dropped > 0 || s.coalesced > 0 {
+		lines = append(lines, fmt.Sprintf(
+			"Dropped: %v "+
+				"Coalesced: %v",
+			s.dropped,
+			s.coalesced))
+	}
+	if s.linkLost > 0 || s.linkDuplicated > 0 || s.linkReordered > 0 {
+		lines = append(lines, fmt.Sprintf(
+			"Links lost: %v "+
+				"duplicated: %v "+
+				"reordered: %v",
+			s.linkLost,
+			s.linkDuplicated,
+			s.linkReordered))
+	}
+	partitions := make([]string, 0)
+	if p := s.partitions.Load(); p != nil {
+		partitions = *p
+	}
+	if len(partitions) > 0 || s.partitionBlocked > 0 {
+		active := "none"
+		if len(partitions) > 0 {
+			active = strings.Join(partitions, ", ")
+		}
+		lines = append(lines, fmt.Sprintf(
+			"Partitioned: %v "+
+				"Blocked: %v",
+			active,
+			s.partitionBlocked))
+	}
+	if divergence := s.divergence.Load(); divergence != nil {
+		lines = append(lines, divergence.String())
+	}
+	return lines
+}
+
 func (s *Stats) Update() {
-	m := s.String()
-	// Clear the entire line
-	s.st.MovePrint(0, 0, strings.Repeat(" ", len(m)+20))
+	lines := s.lines()
+	// Clear every row so detail lines that got shorter or went away leave nothing behind
+	blank := strings.Repeat(" ", statsWidth)
+	for row := 0; row < statsHeight; row++ {
+		s.st.MovePrint(row, 0, blank)
+	}
 	// Position the text at the right edge of the window
-	s.st.MovePrint(1, 0, m)
+	for i, line := range lines {
+		s.st.MovePrint(i+1, 0, line)
+	}
 	glog.GetLogger().Debug("stats update", "Data", s.String())
 	s.st.NoutRefresh()
 }
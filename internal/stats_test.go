@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+	"github.com/ninjapanzer/gogol_channels/renderer/mock"
+)
+
+// screenWindow keeps what was printed where, the way a terminal would
+type screenWindow struct {
+	mock.StatsWindow
+	rows [statsHeight][statsWidth]rune
+}
+
+func (w *screenWindow) MovePrint(y, x int, str string) {
+	for i, r := range str {
+		if x+i < statsWidth {
+			w.rows[y][x+i] = r
+		}
+	}
+}
+
+func (w *screenWindow) row(y int) string {
+	return strings.TrimRight(strings.TrimRight(string(w.rows[y][:]), "\x00"), " ")
+}
+
+type screenRenderer struct {
+	renderer.Renderer
+	window *screenWindow
+}
+
+func (r *screenRenderer) CreateStatsWindow(height, width, y, x int) renderer.StatsWindow {
+	return r.window
+}
+
+func TestStatsUpdateClearsLinesThatWentAway(t *testing.T) {
+	window := &screenWindow{}
+	s := NewStats(&screenRenderer{Renderer: mock.NewMockRenderer(), window: window}, "test")
+
+	s.SetPartitions([]string{"column 40", "row 20"})
+	s.Update()
+	if got := window.row(2); !strings.HasPrefix(got, "Partitioned: column 40, row 20") {
+		t.Fatalf("row 2 = %q, want the partitions", got)
+	}
+
+	s.SetPartitions(nil)
+	s.Update()
+	for y := 2; y < statsHeight; y++ {
+		if got := window.row(y); got != "" {
+			t.Errorf("row %d = %q after the partitions healed, want it blank", y, got)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Boundary decides what a cell on the edge of the grid sees past the border
+type Boundary int
+
+const (
+	// DeadBoundary surrounds the grid with cells that are always dead
+	DeadBoundary Boundary = iota
+	// AliveBoundary surrounds the grid with cells that are always alive
+	AliveBoundary
+	// Torus wraps both the left/right and top/bottom edges
+	Torus
+	// Cylinder wraps the left/right edges and leaves dead cells above and below
+	Cylinder
+	// KleinBottle wraps left/right normally and top/bottom with a mirror flip
+	KleinBottle
+)
+
+func ParseBoundary(s string) (Boundary, error) {
+	switch s {
+	case "dead":
+		return DeadBoundary, nil
+	case "alive":
+		return AliveBoundary, nil
+	case "torus":
+		return Torus, nil
+	case "cylinder":
+		return Cylinder, nil
+	case "klein":
+		return KleinBottle, nil
+	}
+	return DeadBoundary, fmt.Errorf("unknown boundary %q (expected dead, alive, torus, cylinder or klein)", s)
+}
+
+func (b Boundary) String() string {
+	switch b {
+	case AliveBoundary:
+		return "alive"
+	case Torus:
+		return "torus"
+	case Cylinder:
+		return "cylinder"
+	case KleinBottle:
+		return "klein"
+	}
+	return "dead"
+}
+
+// borderState is what a neighbor beyond a fixed border always reports
+func borderState[S comparable](b Boundary, rule Rule[S]) S {
+	var state S
+	if b == AliveBoundary {
+		state = rule.Alive()
+	}
+	return state
+}
+
+// resolve maps a possibly out of range coordinate back onto the grid. inside is
+// false when the coordinate lies beyond a fixed border instead.
+func (b Boundary) resolve(y, x, height, width int) (ny, nx int, inside bool) {
+	ny, nx = y, x
+	if nx < 0 || nx >= width {
+		if b == DeadBoundary || b == AliveBoundary {
+			return 0, 0, false
+		}
+		nx = wrap(nx, width)
+	}
+	if ny < 0 || ny >= height {
+		switch b {
+		case Torus:
+			ny = wrap(ny, height)
+		case KleinBottle:
+			// Crossing the top or bottom edge flips the horizontal axis
+			ny = wrap(ny, height)
+			nx = width - 1 - nx
+		default:
+			return 0, 0, false
+		}
+	}
+	return ny, nx, true
+}
+
+func wrap(v, size int) int {
+	v %= size
+	if v < 0 {
+		v += size
+	}
+	return v
+}
+
+type neighborhoodKind int
+
+const (
+	mooreKind neighborhoodKind = iota
+	vonNeumannKind
+	hexagonalKind
+)
+
+// Neighborhood is the shape of the set of cells a cell listens to
+type Neighborhood struct {
+	kind   neighborhoodKind
+	radius int
+}
+
+// Moore is the square neighborhood of every cell within radius in both axes
+func Moore(radius int) Neighborhood {
+	return Neighborhood{kind: mooreKind, radius: radius}
+}
+
+// VonNeumann is the diamond of cells within radius steps along the axes
+func VonNeumann(radius int) Neighborhood {
+	return Neighborhood{kind: vonNeumannKind, radius: radius}
+}
+
+// Hexagonal is the six cell neighborhood of an "odd-r" hex grid where every odd
+// row is shifted right by half a cell. Wrapping boundaries need an even height
+// to keep row parity consistent across the seam.
+func Hexagonal() Neighborhood {
+	return Neighborhood{kind: hexagonalKind, radius: 1}
+}
+
+// ParseNeighborhood reads "moore", "vonneumann" or "hex", with an optional
+// ":R" radius suffix for the first two (e.g. "moore:2")
+func ParseNeighborhood(s string) (Neighborhood, error) {
+	name, radiusPart, hasRadius := strings.Cut(s, ":")
+	radius := 1
+	if hasRadius {
+		r, err := strconv.Atoi(radiusPart)
+		if err != nil || r < 1 {
+			return Neighborhood{}, fmt.Errorf("neighborhood %q: radius must be a positive integer", s)
+		}
+		radius = r
+	}
+
+	switch name {
+	case "moore":
+		return Moore(radius), nil
+	case "vonneumann":
+		return VonNeumann(radius), nil
+	case "hex":
+		if hasRadius && radius != 1 {
+			return Neighborhood{}, fmt.Errorf("neighborhood %q: hex only supports radius 1", s)
+		}
+		return Hexagonal(), nil
+	}
+	return Neighborhood{}, fmt.Errorf("unknown neighborhood %q (expected moore, vonneumann or hex)", s)
+}
+
+func (n Neighborhood) String() string {
+	switch n.kind {
+	case vonNeumannKind:
+		return "vonneumann:" + strconv.Itoa(n.radius)
+	case hexagonalKind:
+		return "hex"
+	}
+	return "moore:" + strconv.Itoa(n.radius)
+}
+
+func (n Neighborhood) Hexagonal() bool {
+	return n.kind == hexagonalKind
+}
+
+// Radius is how far the neighborhood reaches along either axis
+func (n Neighborhood) Radius() int {
+	return n.radius
+}
+
+// offsets lists the relative (dy, dx) of every neighbor of a cell in row y.
+// The order is fixed so each cell sees its neighbors in the same positions.
+func (n Neighborhood) offsets(y int) [][2]int {
+	if n.kind == hexagonalKind {
+		if y%2 == 0 {
+			return [][2]int{{-1, -1}, {-1, 0}, {0, -1}, {0, 1}, {1, -1}, {1, 0}}
+		}
+		return [][2]int{{-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, 0}, {1, 1}}
+	}
+
+	if n.kind == mooreKind && n.radius == 1 {
+		// Clockwise from north, the canonical order Hensel rules read neighbors in
+		return [][2]int{{-1, 0}, {-1, 1}, {0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}}
+	}
+
+	offsets := make([][2]int, 0)
+	for i := -n.radius; i <= n.radius; i++ {
+		for j := -n.radius; j <= n.radius; j++ {
+			if i == 0 && j == 0 {
+				continue
+			}
+			if n.kind == vonNeumannKind && abs(i)+abs(j) > n.radius {
+				continue
+			}
+			offsets = append(offsets, [2]int{i, j})
+		}
+	}
+	return offsets
+}
+
+// forEachNeighbor visits every neighbor of (y, x). Neighbors past a fixed
+// border are still visited, with inside set to false, so every cell sees the
+// same number of neighbors in the same order.
+func (n Neighborhood) forEachNeighbor(boundary Boundary, y, x, height, width int, visit func(ny, nx int, inside bool)) {
+	for _, offset := range n.offsets(y) {
+		visit(boundary.resolve(y+offset[0], x+offset[1], height, width))
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
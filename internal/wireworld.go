@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math/rand"
+	"strings"
+
+	"github.com/ninjapanzer/gogol_channels/renderer"
+)
+
+// WireState is the state of a WireWorld cell
+type WireState uint8
+
+const (
+	// Empty cells never change
+	Empty WireState = iota
+	// ElectronHead becomes a tail on the next step
+	ElectronHead
+	// ElectronTail becomes conductor on the next step
+	ElectronTail
+	// Conductor becomes a head when one or two of its neighbors are heads
+	Conductor
+)
+
+// WireWorldRule simulates digital circuits. Electrons are a head followed by
+// a tail travelling along wires of conductor.
+type WireWorldRule struct{}
+
+var WireWorld = WireWorldRule{}
+
+var _ Rule[WireState] = WireWorldRule{}
+
+func (r WireWorldRule) String() string {
+	return "WireWorld"
+}
+
+func (r WireWorldRule) Next(state WireState, neighbors []WireState) (WireState, string) {
+	switch state {
+	case ElectronHead:
+		return ElectronTail, "Electron Moves On"
+	case ElectronTail:
+		return Conductor, "Back to Copper"
+	case Conductor:
+		heads := 0
+		for _, n := range neighbors {
+			if n == ElectronHead {
+				heads++
+			}
+		}
+		if heads == 1 || heads == 2 {
+			return ElectronHead, "It's Alive!"
+		}
+		return Conductor, "Waiting for a Spark"
+	}
+	return Empty, "Nothing to See Here"
+}
+
+func (r WireWorldRule) Alive() WireState {
+	return Conductor
+}
+
+// Spawn lays conductor, electrons are painted on purpose with the mouse tools
+func (r WireWorldRule) Spawn(rng *rand.Rand) WireState {
+	return Conductor
+}
+
+func (r WireWorldRule) Glyph(state WireState) string {
+	switch state {
+	case ElectronHead:
+		return "@"
+	case ElectronTail:
+		return "~"
+	case Conductor:
+		return "#"
+	}
+	return renderer.DeadGlyph
+}
+
+func (r WireWorldRule) Palette() renderer.Palette {
+	return renderer.Palette{
+		"@": {Color: color.RGBA{64, 128, 255, 255}},
+		"~": {Color: color.RGBA{255, 64, 64, 255}},
+		"#": {Color: color.RGBA{255, 200, 0, 255}},
+	}
+}
+
+// Tools maps the mouse tool keys to the state each one paints
+func (r WireWorldRule) Tools() map[renderer.Key]WireState {
+	return map[renderer.Key]WireState{
+		renderer.KEY_CONDUCTOR: Conductor,
+		renderer.KEY_HEAD:      ElectronHead,
+		renderer.KEY_TAIL:      ElectronTail,
+	}
+}
+
+// ParseWireWorldPattern reads a plain text WireWorld pattern. Each line is a
+// row of cells where '#' is conductor, '@' an electron head, '~' an electron
+// tail and '.' or a space is empty. Lines starting with '!' are comments.
+// Rows may have different lengths, missing cells are empty.
+func ParseWireWorldPattern(in io.Reader) ([][]WireState, error) {
+	pattern := make([][]WireState, 0)
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(text, "!") {
+			continue
+		}
+
+		row := make([]WireState, len(text))
+		for x, ch := range []byte(text) {
+			switch ch {
+			case '#':
+				row[x] = Conductor
+			case '@':
+				row[x] = ElectronHead
+			case '~':
+				row[x] = ElectronTail
+			case '.', ' ':
+				row[x] = Empty
+			default:
+				return nil, fmt.Errorf("pattern line %d: unknown cell %q", line, ch)
+			}
+		}
+		pattern = append(pattern, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("pattern: %w", err)
+	}
+	return pattern, nil
+}
@@ -1,134 +1,393 @@
 package internal
 
 import (
-	"fmt"
+	"context"
 	glog "github.com/ninjapanzer/gogol_channels/log"
 	"github.com/ninjapanzer/gogol_channels/renderer"
 	"math/rand"
-	"time"
+	"sync/atomic"
 )
 
-type ChannelWorld[T ChannelCell] struct {
+// ChannelWorld runs one goroutine per cell of state S, with S decided by the rule
+type ChannelWorld[S comparable] struct {
 	r        renderer.Renderer
 	s        *Stats
-	cells    [][]*ChannelCell
+	cells    [][]*ChannelCell[S]
 	initProb float64
+	pattern  [][]S
+	rule     Rule[S]
+	opts     worldOptions
+	cancel   context.CancelFunc
+	exited   chan struct{}
+	running  int
+	// paused is flipped by the UI goroutine and read by anything drawing the world
+	paused atomic.Bool
 }
 
-func NewChannelWorld[T ChannelCell](r renderer.Renderer, prob float64) *ChannelWorld[T] {
+func NewChannelWorld[S comparable](r renderer.Renderer, prob float64, rule Rule[S], opts ...WorldOption) *ChannelWorld[S] {
+	o := defaultWorldOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r.SetLayout(layoutFor(o.neighborhood))
+	r.SetPalette(rule.Palette())
+
 	y, x := r.Dimensions()
-	cells := make([][]*ChannelCell, y)
+	var dead S
+	cells := make([][]*ChannelCell[S], y)
 	for i := range cells {
-		cells[i] = make([]*ChannelCell, x)
+		cells[i] = make([]*ChannelCell[S], x)
 		for j := range cells[i] {
-			cells[i][j] = NewChannelCell(false, fmt.Sprintf("%s-%s", i, j))
+			cells[i][j] = NewChannelCell(dead, Location{Y: i, X: j}, rule)
 		}
 	}
-	return &ChannelWorld[T]{
+	s := NewStats(r, "bottom")
+	if speciated, ok := rule.(Speciated[S]); ok {
+		glyphs := make([]string, 0)
+		for _, species := range speciated.Species() {
+			glyphs = append(glyphs, rule.Glyph(species))
+		}
+		s.SetSpecies(glyphs)
+	}
+
+	return &ChannelWorld[S]{
 		r:        r,
-		s:        NewStats(r, "bottom"),
+		s:        s,
 		cells:    cells,
 		initProb: prob,
+		rule:     rule,
+		opts:     o,
 	}
 }
 
-func (w *ChannelWorld[T]) Refresh() {
+func (w *ChannelWorld[S]) Refresh() {
 	w.r.Clear()
 	w.DrawWorld()
 	w.r.BufferUpdate()
 }
 
-func (w *ChannelWorld[T]) ComputeState() {
+func (w *ChannelWorld[S]) ComputeState() {
 }
 
-func (w *ChannelWorld[T]) Cells() [][]*ChannelCell {
+func (w *ChannelWorld[S]) Cells() [][]*ChannelCell[S] {
 	return w.cells
 }
 
-func (w *ChannelWorld[T]) Bootstrap() {
+// SetPattern makes Bootstrap place pattern in the middle of the world instead
+// of seeding it at random. It must be called before Bootstrap.
+func (w *ChannelWorld[S]) SetPattern(pattern [][]S) {
+	w.pattern = pattern
+}
+
+// Bootstrap seeds the world and starts every cell and stats goroutine. They
+// all run until ctx is cancelled or Shutdown is called.
+// Bootstrap starts every cell. Nothing is started when a partition does not
+// fit the world.
+func (w *ChannelWorld[S]) Bootstrap(ctx context.Context) error {
+	for _, p := range w.opts.partitions {
+		if err := p.check(len(w.cells), len(w.cells[0])); err != nil {
+			return err
+		}
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	// publish, heartbeat and listen for every cell plus the stats collector and
+	// the partition schedule
+	perCell := 3
+	if w.opts.mode == Sync {
+		// publish and the generation loop
+		perCell = 2
+	}
+	if w.opts.links.Enabled() || len(w.opts.partitions) > 0 {
+		// At most one link goroutine for every neighbor
+		perCell += len(w.opts.neighborhood.offsets(0))
+	}
+	w.exited = make(chan struct{}, len(w.cells)*len(w.cells[0])*perCell+2)
+
+	w.s.bind(ctx)
+	w.spawn(ctx, w.s.collectStats)
+
 	w.initializeProbabilisticDistributionOfLife(w.initProb)
-	w.setupNeighborhood()
+	w.setupNeighborhood(ctx)
+	return nil
+}
+
+// Shutdown cancels everything started by Bootstrap and waits for each goroutine to exit
+func (w *ChannelWorld[S]) Shutdown() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	for ; w.running > 0; w.running-- {
+		<-w.exited
+	}
+}
+
+// Pause freezes every cell's read cycle. In async mode paused cells keep
+// draining their neighbors' messages, so heartbeats keep flowing and edits made
+// while paused reach the neighborhood before the next step. In sync mode the
+// next step's generation broadcast carries them instead.
+func (w *ChannelWorld[S]) Pause() {
+	w.paused.Store(true)
+	w.s.SetPaused(true)
+	w.command(cmdPause)
+}
+
+// Resume lets every cell continue reading on its own clock
+func (w *ChannelWorld[S]) Resume() {
+	w.paused.Store(false)
+	w.s.SetPaused(false)
+	w.command(cmdResume)
 }
 
-func (w *ChannelWorld[T]) initializeProbabilisticDistributionOfLife(prob float64) {
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+// TogglePause pauses a running world or resumes a paused one
+func (w *ChannelWorld[S]) TogglePause() {
+	if w.paused.Load() {
+		w.Resume()
+	} else {
+		w.Pause()
+	}
+}
+
+// Step advances every cell by exactly one read cycle, pausing the world first if needed
+func (w *ChannelWorld[S]) Step() {
+	if !w.paused.Load() {
+		w.Pause()
+	}
+	w.command(cmdStep)
+}
+
+// SetCell changes a single cell without broadcasting, ignoring coordinates
+// outside the grid. Neighbors learn about it on the next heartbeat.
+func (w *ChannelWorld[S]) SetCell(y, x int, state S) {
+	if y < 0 || y >= len(w.cells) || x < 0 || x >= len(w.cells[y]) {
+		return
+	}
+	w.cells[y][x].Paint(state)
+}
+
+// SetRates paints a cell with its own read and broadcast rates in
+// milliseconds, ignoring coordinates outside the grid. Zero rates hand the
+// cell back to the global rates.
+func (w *ChannelWorld[S]) SetRates(y, x int, readRate, broadcastRate int64) {
+	if y < 0 || y >= len(w.cells) || x < 0 || x >= len(w.cells[y]) {
+		return
+	}
+	w.cells[y][x].SetRates(readRate, broadcastRate)
+}
+
+func (w *ChannelWorld[S]) Paused() bool {
+	return w.paused.Load()
+}
+
+func (w *ChannelWorld[S]) command(cmd cellCommand) {
+	for _, row := range w.cells {
+		for _, cell := range row {
+			cell.command(cmd)
+		}
+	}
+}
+
+// spawn runs fn in a goroutine that reports on exited when it returns
+func (w *ChannelWorld[S]) spawn(ctx context.Context, fn func(context.Context)) {
+	w.running++
+	go func() {
+		fn(ctx)
+		w.exited <- struct{}{}
+	}()
+}
+
+func (w *ChannelWorld[S]) initializeProbabilisticDistributionOfLife(prob float64) {
+	rng := rand.New(rand.NewSource(w.opts.seed))
+	backpressure := w.opts.backpressure
+	if w.opts.mode == Sync {
+		// A dropped message would hold the generation barrier forever
+		backpressure.Policy = Block
+	}
 
 	for i, _ := range w.cells {
 		for j, _ := range w.cells[i] {
 			target := w.cells[i][j]
 			target.SetRenderer(w.DrawCell(i, j))
 			target.SetStatsFunc(w.s.AddEvent)
-			if rng.Float64() < prob {
-				target.SilentSetState(true)
+			target.SetMaxStaleness(w.opts.maxStaleness)
+			target.SetCausality(w.opts.causality)
+			target.SetBackpressure(backpressure)
+			if w.pattern != nil {
+				target.SilentSetState(patternState(w.pattern, i, j, len(w.cells), len(w.cells[i])))
+			} else if rng.Float64() < prob {
+				target.SilentSetState(w.rule.Spawn(rng))
 			}
 			w.DrawCell(i, j)
 		}
 	}
+
+	if w.opts.noise.Enabled() {
+		// Seeds are drawn after the population so noise never changes the starting pattern
+		for _, row := range w.cells {
+			for _, cell := range row {
+				cell.SetNoise(w.opts.noise, rand.New(rand.NewSource(rng.Int63())))
+			}
+		}
+	}
+	if w.opts.clock.Enabled() {
+		// Drawn last so desynchronizing the clocks keeps the same noise
+		for _, row := range w.cells {
+			for _, cell := range row {
+				cell.SetClock(w.opts.clock, rng)
+			}
+		}
+	}
 	w.r.BufferUpdate()
 }
 
-func (w *ChannelWorld[T]) setupNeighborhood() {
+func (w *ChannelWorld[S]) setupNeighborhood(ctx context.Context) {
 	height := len(w.cells)
 	width := len(w.cells[0])
+	border := borderState(w.opts.boundary, w.rule)
+
+	subscribe := func(from, to *ChannelCell[S]) <-chan CellMessage[S] {
+		return from.Subscribe()
+	}
+	links := make([]*link[S], 0)
+	schedule := newPartitionSchedule(w.opts.partitions, w.s)
+	if faults := w.linkFaults(); faults.Enabled() || len(schedule.partitions) > 0 {
+		// Links draw from their own generator so faults never change the starting population
+		rng := rand.New(rand.NewSource(w.opts.seed + 1))
+		subscribe = func(from, to *ChannelCell[S]) <-chan CellMessage[S] {
+			partitions := schedule.cutting(from.position, to.position)
+			if !faults.Enabled() && len(partitions) == 0 {
+				// Nothing ever happens to this edge, so there is no point in a link
+				return from.Subscribe()
+			}
+			l := &link[S]{
+				in:     from.Subscribe(),
+				out:    make(chan CellMessage[S], from.backpressure.Buffer),
+				faults: faults,
+				rng:    rand.New(rand.NewSource(rng.Int63())),
+				sender: from,
+				drawFault: func(fault renderer.LinkFault) {
+					w.r.DrawLinkFault(from.position.Y, from.position.X, to.position.Y, to.position.X, fault)
+				},
+				partitions: partitions,
+				// A dropped generation message would hold the generation barrier forever
+				park: w.opts.mode == Sync,
+				// and one overtaken by the next generation would be read as its own
+				fifo: w.opts.mode == Sync,
+			}
+			links = append(links, l)
+			return l.out
+		}
+	}
 
 	for i, _ := range w.cells {
 		for j, _ := range w.cells[i] {
-			linkNeighbors(w.cells, w.cells[i][j], i, j, width, height)
+			linkNeighbors(w.cells, w.cells[i][j], i, j, width, height, w.opts.neighborhood, w.opts.boundary, border, subscribe)
 		}
 	}
-}
+	for _, l := range links {
+		w.spawn(ctx, l.run)
+	}
+	if len(schedule.partitions) > 0 {
+		w.spawn(ctx, schedule.run)
+	}
 
-func linkNeighbors(cells [][]*ChannelCell, cell *ChannelCell, y, x, width, height int) {
-	for i := -1; i <= 1; i++ {
-		for j := -1; j <= 1; j++ {
-			if i == 0 && j == 0 {
-				continue
-			}
-			if y+i < 0 {
-				continue
-			}
-			if y+i >= height {
-				continue
-			}
-			if x+j < 0 {
-				continue
-			}
-			if x+j >= width {
+	// Only start the cells once every edge is wired so publishers see a complete subscriber list
+	for i, _ := range w.cells {
+		for j, _ := range w.cells[i] {
+			cell := w.cells[i][j]
+			cell.bind(ctx)
+			w.spawn(ctx, cell.publish)
+			if w.opts.mode == Sync {
+				// Heartbeats would break the one message per generation contract
+				w.spawn(ctx, cell.LiveInGenerations)
 				continue
 			}
-
-			glog.GetLogger().Info("Adding Neighbor", "CX", x, "CY", y, "TX", x+i, "TY", y+j)
-			cell.AddChannel(cells[y+i][x+j].BroadcastChan())
-			if cells[y+i][x+j].State() {
-				cell.AddNeighborState(1)
-			} else {
-				cell.AddNeighborState(0)
-			}
+			w.spawn(ctx, cell.heartbeat)
+			w.spawn(ctx, cell.Live)
 		}
 	}
-	go cell.heartbeat()
-	go cell.Live()
 }
 
-func (w *ChannelWorld[T]) DrawCell(y, x int) func(bool) {
-	return func(state bool) {
-		if state {
-			w.r.DrawAt(y, x, "0")
-		} else {
-			w.r.DrawAt(y, x, "-")
+func linkNeighbors[S comparable](cells [][]*ChannelCell[S], cell *ChannelCell[S], y, x, width, height int, neighborhood Neighborhood, boundary Boundary, border S, subscribe func(from, to *ChannelCell[S]) <-chan CellMessage[S]) {
+	neighborhood.forEachNeighbor(boundary, y, x, height, width, func(ny, nx int, inside bool) {
+		if !inside {
+			// A nil channel never delivers, so the border keeps its fixed state forever
+			glog.GetLogger().Info("Adding Border", "CX", x, "CY", y, "State", border)
+			cell.AddChannel(nil)
+			cell.AddNeighborState(border)
+			return
 		}
+
+		glog.GetLogger().Info("Adding Neighbor", "CX", x, "CY", y, "TX", nx, "TY", ny)
+		cell.AddChannel(subscribe(cells[ny][nx], cell))
+		cell.AddNeighborState(cells[ny][nx].State())
+	})
+}
+
+// linkFaults are the faults links inject in the world's mode. Sync mode only
+// delays and duplicates, since a lost or overtaken generation message would
+// hold the generation barrier forever. Its links also deliver in order despite
+// the jitter.
+func (w *ChannelWorld[S]) linkFaults() LinkFaults {
+	faults := w.opts.links
+	if w.opts.mode == Sync {
+		faults.Loss = 0
+		faults.Reorder = 0
+	}
+	return faults
+}
+
+// patternState is the state pattern gives cell (y, x) when it is centred on a
+// grid of height by width. Cells the pattern does not cover are dead.
+func patternState[S comparable](pattern [][]S, y, x, height, width int) S {
+	var state S
+	patternWidth := 0
+	for _, row := range pattern {
+		patternWidth = max(patternWidth, len(row))
+	}
+
+	py := y - (height-len(pattern))/2
+	if py < 0 || py >= len(pattern) {
+		return state
+	}
+	px := x - (width-patternWidth)/2
+	if px < 0 || px >= len(pattern[py]) {
+		return state
+	}
+	return pattern[py][px]
+}
+
+// layoutFor tells the renderer whether cells sit on a square or hex grid
+func layoutFor(neighborhood Neighborhood) renderer.Layout {
+	if neighborhood.Hexagonal() {
+		return renderer.HexLayout
+	}
+	return renderer.SquareLayout
+}
+
+// drawState draws a single cell as a value when the rule is continuous and as
+// its glyph otherwise
+func drawState[S comparable](r renderer.Renderer, rule Rule[S], y, x int, state S) {
+	if valued, ok := rule.(Valued[S]); ok {
+		r.DrawValueAt(y, x, valued.Value(state))
+		return
+	}
+	r.DrawAt(y, x, rule.Glyph(state))
+}
+
+func (w *ChannelWorld[S]) DrawCell(y, x int) func(S) {
+	return func(state S) {
+		drawState(w.r, w.rule, y, x, state)
 		w.r.BufferUpdate()
 	}
 }
 
-func (w *ChannelWorld[T]) DrawWorld() {
+func (w *ChannelWorld[S]) DrawWorld() {
 	for y, row := range w.Cells() {
 		for x, cell := range row {
-			if cell.State() {
-				w.r.DrawAt(y, x, "0")
-			} else {
-				w.r.DrawAt(y, x, "-")
-			}
+			drawState(w.r, w.rule, y, x, cell.State())
 		}
 	}
 }
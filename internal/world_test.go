@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ninjapanzer/gogol_channels/renderer/mock"
+)
+
+func TestChannelWorldShutdownLeavesNoGoroutines(t *testing.T) {
+	readRate := atomic.SwapInt64(&GlobalReadRate, 5)
+	broadcastRate := atomic.SwapInt64(&GlobalBroadcastRate, 5)
+	t.Cleanup(func() {
+		atomic.StoreInt64(&GlobalReadRate, readRate)
+		atomic.StoreInt64(&GlobalBroadcastRate, broadcastRate)
+	})
+
+	faults, err := NewLinkFaults(time.Millisecond, time.Millisecond, 0.1, 0.1, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partitions := []Partition{{Duration: time.Hour, Column: true, Index: 5}}
+
+	tests := []struct {
+		name  string
+		opts  []WorldOption
+		pause bool
+	}{
+		{name: "async"},
+		{name: "sync", opts: []WorldOption{WithMode(Sync)}},
+		{name: "paused", pause: true},
+		{name: "coalescing", opts: []WorldOption{WithBackpressure(Backpressure{Policy: Coalesce, Buffer: 2})}},
+		{name: "faulty links", opts: []WorldOption{WithBoundary(Torus), WithLinkFaults(faults)}},
+		{name: "partitioned sync", opts: []WorldOption{WithMode(Sync), WithPartitions(partitions)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline := runtime.NumGoroutine()
+
+			w := NewChannelWorld[bool](mock.NewMockRenderer(), 0.4, Conway, append(tt.opts, WithSeed(7))...)
+			ctx, cancel := context.WithCancel(context.Background())
+			if err := w.Bootstrap(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if tt.pause {
+				w.Pause()
+			}
+			time.Sleep(50 * time.Millisecond)
+			if runtime.NumGoroutine() <= baseline {
+				t.Fatalf("%d goroutines after Bootstrap, want more than %d", runtime.NumGoroutine(), baseline)
+			}
+
+			cancel()
+			w.Shutdown()
+
+			// Goroutines report to Shutdown just before they return
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if n := runtime.NumGoroutine(); n > baseline {
+				buf := make([]byte, 1<<16)
+				t.Fatalf("%d goroutines left after Shutdown, want %d\n%s", n, baseline, buf[:runtime.Stack(buf, true)])
+			}
+		})
+	}
+}
+
+func TestChannelWorldPaintsBeforeStep(t *testing.T) {
+	// Cells only advance when stepped
+	readRate := atomic.SwapInt64(&GlobalReadRate, int64(time.Hour/time.Millisecond))
+	t.Cleanup(func() { atomic.StoreInt64(&GlobalReadRate, readRate) })
+
+	w := NewChannelWorld[bool](mock.NewMockRenderer(), 0, Conway, WithMode(Sync))
+	if err := w.Bootstrap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Shutdown()
+	w.Pause()
+
+	// Paints reach the cells before the step that follows them
+	for x := 3; x <= 5; x++ {
+		w.SetCell(4, x, true)
+	}
+	w.Step()
+	awaitGeneration(t, w, 1)
+
+	want := []string{
+		"..........",
+		"..........",
+		"..........",
+		"....#.....",
+		"....#.....",
+		"....#.....",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+	}
+	if got := render(w.Cells()); !slices.Equal(got, want) {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
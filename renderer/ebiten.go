@@ -4,9 +4,9 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"image/color"
 	"log"
+	"math"
 	"strconv"
 	"sync"
-	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -53,37 +53,46 @@ type EbitenGame struct {
 	renderer *EbitenRenderer
 }
 
+// toolKeys maps the keys that pick a mouse tool to the tool they select
+var toolKeys = map[ebiten.Key]Key{
+	ebiten.KeyB: KEY_BRUSH,
+	ebiten.KeyX: KEY_ERASE,
+	ebiten.KeyC: KEY_CONDUCTOR,
+	ebiten.KeyE: KEY_HEAD,
+	ebiten.KeyT: KEY_TAIL,
+}
+
 func (g *EbitenGame) Update() error {
 	// Handle keyboard input
 	g.renderer.charMutex.Lock()
 	defer g.renderer.charMutex.Unlock()
 
-	// Update fading cells
-	now := time.Now()
-	delta := now.Sub(g.renderer.lastFrameTime).Seconds()
-	g.renderer.lastFrameTime = now
-
-	// Fade rate: how much opacity decreases per second
-	fadeRate := 1.0 // Reduced fade rate to make the fading effect more visible
+	// Check for 'q' key press
+	if inpututil.IsKeyJustPressed(ebiten.KeyQ) {
+		g.renderer.charBuffer = append(g.renderer.charBuffer, Key('q'))
+	}
 
-	// Update fading cells
-	for y := range g.renderer.fadingCells {
-		for x := range g.renderer.fadingCells[y] {
-			if g.renderer.fadingCells[y][x] > 0 {
-				// Reduce opacity based on time delta
-				g.renderer.fadingCells[y][x] -= float64(fadeRate) * delta
+	// Pause/resume and single-step controls
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.renderer.charBuffer = append(g.renderer.charBuffer, KEY_PAUSE)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		g.renderer.charBuffer = append(g.renderer.charBuffer, KEY_STEP)
+	}
 
-				// Ensure opacity doesn't go below 0
-				if g.renderer.fadingCells[y][x] < 0 {
-					g.renderer.fadingCells[y][x] = 0
-				}
-			}
+	// Mouse tool selection
+	for key, tool := range toolKeys {
+		if inpututil.IsKeyJustPressed(key) {
+			g.renderer.ratePaint = false
+			g.renderer.syncSliders()
+			g.renderer.charBuffer = append(g.renderer.charBuffer, tool)
 		}
 	}
 
-	// Check for 'q' key press
-	if inpututil.IsKeyJustPressed(ebiten.KeyQ) {
-		g.renderer.charBuffer = append(g.renderer.charBuffer, Key('q'))
+	// 'r' toggles painting rate regions, which the sliders then pick the rates for
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		g.renderer.ratePaint = !g.renderer.ratePaint
+		g.renderer.syncSliders()
 	}
 
 	// Check for window close
@@ -108,19 +117,15 @@ func (g *EbitenGame) Update() error {
 			mouseY >= sliderY-5 && mouseY <= sliderY+sliderHeight+5 {
 			g.renderer.sliderDragging = "read"
 			g.renderer.sliderReadX = mouseX - sliderX
-			g.renderer.readRate = int64(g.renderer.sliderReadX * 1000 / sliderWidth)
-			if g.renderer.rateCallback != nil {
-				g.renderer.rateCallback(g.renderer.readRate, g.renderer.broadcastRate)
-			}
+			g.renderer.applySliders(sliderWidth)
 		} else if mouseX >= sliderX && mouseX <= sliderX+sliderWidth &&
 			mouseY >= sliderY+40 && mouseY <= sliderY+45+sliderHeight+5 {
 			// Check if click is on broadcast rate slider
 			g.renderer.sliderDragging = "broadcast"
 			g.renderer.sliderBroadcastX = mouseX - sliderX
-			g.renderer.broadcastRate = int64(g.renderer.sliderBroadcastX * 1000 / sliderWidth)
-			if g.renderer.rateCallback != nil {
-				g.renderer.rateCallback(g.renderer.readRate, g.renderer.broadcastRate)
-			}
+			g.renderer.applySliders(sliderWidth)
+		} else if g.renderer.ratePaint {
+			g.renderer.paintRegion(mouseX, mouseY, g.renderer.brushReadRate, g.renderer.brushBroadcastRate)
 		} else {
 			// Regular mouse click
 			g.renderer.mousePressed = true
@@ -137,10 +142,7 @@ func (g *EbitenGame) Update() error {
 			if g.renderer.sliderReadX > sliderWidth {
 				g.renderer.sliderReadX = sliderWidth
 			}
-			g.renderer.readRate = int64(g.renderer.sliderReadX * 1000 / sliderWidth)
-			if g.renderer.rateCallback != nil {
-				g.renderer.rateCallback(g.renderer.readRate, g.renderer.broadcastRate)
-			}
+			g.renderer.applySliders(sliderWidth)
 		} else if g.renderer.sliderDragging == "broadcast" {
 			g.renderer.sliderBroadcastX = mouseX - sliderX
 			if g.renderer.sliderBroadcastX < 0 {
@@ -149,10 +151,9 @@ func (g *EbitenGame) Update() error {
 			if g.renderer.sliderBroadcastX > sliderWidth {
 				g.renderer.sliderBroadcastX = sliderWidth
 			}
-			g.renderer.broadcastRate = int64(g.renderer.sliderBroadcastX * 1000 / sliderWidth)
-			if g.renderer.rateCallback != nil {
-				g.renderer.rateCallback(g.renderer.readRate, g.renderer.broadcastRate)
-			}
+			g.renderer.applySliders(sliderWidth)
+		} else if g.renderer.ratePaint {
+			g.renderer.paintRegion(mouseX, mouseY, g.renderer.brushReadRate, g.renderer.brushBroadcastRate)
 		} else {
 			// Regular mouse drag
 			g.renderer.mousePressed = true
@@ -163,6 +164,9 @@ func (g *EbitenGame) Update() error {
 		g.renderer.sliderDragging = ""
 		g.renderer.mousePressed = false
 		g.renderer.charBuffer = append(g.renderer.charBuffer, KEY_MOUSE_RELEASE)
+	} else if g.renderer.ratePaint && ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) {
+		// Right dragging hands painted cells back to the global rates
+		g.renderer.paintRegion(mouseX, mouseY, 0, 0)
 	} else {
 		g.renderer.mousePressed = false
 	}
@@ -185,26 +189,42 @@ func (g *EbitenGame) Draw(screen *ebiten.Image) {
 	for y, row := range g.renderer.buffer {
 		for x, cell := range row {
 			// Calculate cell position and size
-			cellX := float64(x * g.renderer.cellSize)
-			cellY := float64(y * g.renderer.cellSize)
+			cellX, cellY := g.renderer.cellOrigin(y, x)
 			cellSize := float64(g.renderer.cellSize - 1) // Leave a small gap between cells
 
+			// Continuous states fill the whole cell with their colormap color
+			if g.renderer.field {
+				ebitenutil.DrawRect(screen, cellX, cellY, cellSize, cellSize, colormap(g.renderer.values[y][x]))
+			}
+
+			// Tint cells painted with their own rates
+			if region := g.renderer.regions[y][x]; region.readRate > 0 {
+				ebitenutil.DrawRect(screen, cellX, cellY, cellSize, cellSize, regionTint(region.readRate))
+			}
+			if g.renderer.field {
+				continue
+			}
+
 			// Define cell padding to make cells smaller and leave space for communication lines
 			cellPadding := 3.0 // Padding around cells to make them smaller
 
 			// Draw a cube (rectangle) for the cell if it's alive
-			if cell == "0" {
-				// Draw the cell as a smaller white square with padding
+			if g.renderer.alive(cell) {
+				// Draw the cell as a smaller square with padding, colored by the palette
+				cellColor := offWhite
+				if style, ok := g.renderer.palette[cell]; ok {
+					cellColor = style.Color
+				}
 				ebitenutil.DrawRect(screen, cellX + cellPadding, cellY + cellPadding, 
-					cellSize - (cellPadding * 2), cellSize - (cellPadding * 2), offWhite)
+					cellSize - (cellPadding * 2), cellSize - (cellPadding * 2), cellColor)
 			}
 
 			// Draw fading cells
 			fadeOpacity := g.renderer.fadingCells[y][x]
-			if fadeOpacity > 0 && cell != "0" {
+			if fadeOpacity > 0 {
 				// Create a color with the appropriate opacity
-				alpha := uint8(fadeOpacity * 255)
-				fadeColor := color.RGBA{240, 240, 240, alpha} // Off-white with fading alpha
+				fadeColor := g.renderer.palette[cell].Color
+				fadeColor.A = uint8(fadeOpacity * 255)
 
 				// Draw the fading cell
 				ebitenutil.DrawRect(screen, cellX + cellPadding, cellY + cellPadding, 
@@ -214,47 +234,43 @@ func (g *EbitenGame) Draw(screen *ebiten.Image) {
 			// Draw blue indicator for communication
 			if g.renderer.communications[y][x] {
 				// Check adjacent cells and draw blue lines between them if they're alive
-				for dy := -1; dy <= 1; dy++ {
-					for dx := -1; dx <= 1; dx++ {
-						// Skip the cell itself
-						if dx == 0 && dy == 0 {
-							continue
-						}
-
-						// Check if the adjacent cell is within bounds
-						adjY, adjX := y+dy, x+dx
-						if adjY >= 0 && adjY < len(g.renderer.buffer) && 
-							adjX >= 0 && adjX < len(g.renderer.buffer[0]) {
-
-							// Calculate start and end points for the line
-							startX := cellX + cellSize/2
-							startY := cellY + cellSize/2
-							endX := float64(adjX * g.renderer.cellSize) + cellSize/2
-							endY := float64(adjY * g.renderer.cellSize) + cellSize/2
-
-							// Check if the adjacent cell is alive
-							if g.renderer.buffer[adjY][adjX] == "0" {
-								// Draw multiple lines to create a thicker appearance
-								// Main line
-								ebitenutil.DrawLine(screen, startX, startY, endX, endY, blueLineColor)
-								// Additional lines with slight offsets to create thickness
-								ebitenutil.DrawLine(screen, startX+1, startY, endX+1, endY, blueLineColor)
-								ebitenutil.DrawLine(screen, startX-1, startY, endX-1, endY, blueLineColor)
-								ebitenutil.DrawLine(screen, startX, startY+1, endX, endY+1, blueLineColor)
-								ebitenutil.DrawLine(screen, startX, startY-1, endX, endY-1, blueLineColor)
-							} else if g.renderer.buffer[adjY][adjX] == "-" {
-								// If the adjacent cell is dead, mark it as receiving a broadcast
-								g.renderer.deadCellBroadcasts[adjY][adjX] = true
-
-								// Draw gray lines to dead cells that are receiving broadcasts
-								// Main line
-								ebitenutil.DrawLine(screen, startX, startY, endX, endY, grayLineColor)
-								// Additional lines with slight offsets to create thickness
-								ebitenutil.DrawLine(screen, startX+1, startY, endX+1, endY, grayLineColor)
-								ebitenutil.DrawLine(screen, startX-1, startY, endX-1, endY, grayLineColor)
-								ebitenutil.DrawLine(screen, startX, startY+1, endX, endY+1, grayLineColor)
-								ebitenutil.DrawLine(screen, startX, startY-1, endX, endY-1, grayLineColor)
-							}
+				for _, offset := range g.renderer.adjacentOffsets(y) {
+					dy, dx := offset[0], offset[1]
+
+					// Check if the adjacent cell is within bounds
+					adjY, adjX := y+dy, x+dx
+					if adjY >= 0 && adjY < len(g.renderer.buffer) && 
+						adjX >= 0 && adjX < len(g.renderer.buffer[0]) {
+
+						// Calculate start and end points for the line
+						startX := cellX + cellSize/2
+						startY := cellY + cellSize/2
+						adjCellX, adjCellY := g.renderer.cellOrigin(adjY, adjX)
+						endX := adjCellX + cellSize/2
+						endY := adjCellY + cellSize/2
+
+						// Check if the adjacent cell is alive
+						if g.renderer.alive(g.renderer.buffer[adjY][adjX]) {
+							// Draw multiple lines to create a thicker appearance
+							// Main line
+							ebitenutil.DrawLine(screen, startX, startY, endX, endY, blueLineColor)
+							// Additional lines with slight offsets to create thickness
+							ebitenutil.DrawLine(screen, startX+1, startY, endX+1, endY, blueLineColor)
+							ebitenutil.DrawLine(screen, startX-1, startY, endX-1, endY, blueLineColor)
+							ebitenutil.DrawLine(screen, startX, startY+1, endX, endY+1, blueLineColor)
+							ebitenutil.DrawLine(screen, startX, startY-1, endX, endY-1, blueLineColor)
+						} else if g.renderer.buffer[adjY][adjX] == DeadGlyph {
+							// If the adjacent cell is dead, mark it as receiving a broadcast
+							g.renderer.deadCellBroadcasts[adjY][adjX] = true
+
+							// Draw gray lines to dead cells that are receiving broadcasts
+							// Main line
+							ebitenutil.DrawLine(screen, startX, startY, endX, endY, grayLineColor)
+							// Additional lines with slight offsets to create thickness
+							ebitenutil.DrawLine(screen, startX+1, startY, endX+1, endY, grayLineColor)
+							ebitenutil.DrawLine(screen, startX-1, startY, endX-1, endY, grayLineColor)
+							ebitenutil.DrawLine(screen, startX, startY+1, endX, endY+1, grayLineColor)
+							ebitenutil.DrawLine(screen, startX, startY-1, endX, endY-1, grayLineColor)
 						}
 					}
 				}
@@ -262,6 +278,33 @@ func (g *EbitenGame) Draw(screen *ebiten.Image) {
 		}
 	}
 
+	// Flash links that just lost, duplicated or reordered a message. Link
+	// goroutines record the faults, so the flashes are only touched under charMutex.
+	g.renderer.charMutex.Lock()
+	for y, row := range g.renderer.linkFlashes {
+		for x, flash := range row {
+			if flash.frames <= 0 {
+				continue
+			}
+			g.renderer.linkFlashes[y][x].frames--
+
+			dy, dx := y-flash.fromY, x-flash.fromX
+			if dy*2 > len(g.renderer.buffer) || -dy*2 > len(g.renderer.buffer) || dx*2 > g.renderer.width || -dx*2 > g.renderer.width {
+				// A link wrapped around the edge would cross the whole grid
+				continue
+			}
+			half := float64(g.renderer.cellSize-1) / 2
+			fromX, fromY := g.renderer.cellOrigin(flash.fromY, flash.fromX)
+			toX, toY := g.renderer.cellOrigin(y, x)
+			flashColor := linkFaultColors[flash.fault]
+			flashColor.A = uint8(255 * flash.frames / linkFlashFrames)
+			ebitenutil.DrawLine(screen, fromX+half, fromY+half, toX+half, toY+half, flashColor)
+			ebitenutil.DrawLine(screen, fromX+half+1, fromY+half, toX+half+1, toY+half, flashColor)
+			ebitenutil.DrawLine(screen, fromX+half, fromY+half+1, toX+half, toY+half+1, flashColor)
+		}
+	}
+	g.renderer.charMutex.Unlock()
+
 	// Draw stats windows
 	for _, sw := range g.renderer.statsWindows {
 		// Calculate the background rectangle dimensions
@@ -308,19 +351,30 @@ func (g *EbitenGame) Draw(screen *ebiten.Image) {
 	sliderHeight := 20
 	sliderX := 50
 
+	// In rate paint mode the sliders pick the rates painted onto regions
+	readRate, broadcastRate, label := g.renderer.readRate, g.renderer.broadcastRate, ""
+	if g.renderer.ratePaint {
+		readRate, broadcastRate, label = g.renderer.brushReadRate, g.renderer.brushBroadcastRate, "Region "
+	}
+
 	// Draw read rate slider
-	text.Draw(screen, "Read Rate: " + strconv.FormatInt(g.renderer.readRate, 10) + "ms", g.renderer.fontFace, sliderX, sliderY - 5, color.White)
+	text.Draw(screen, label + "Read Rate: " + strconv.FormatInt(readRate, 10) + "ms", g.renderer.fontFace, sliderX, sliderY - 5, color.White)
 	ebitenutil.DrawRect(screen, float64(sliderX), float64(sliderY), float64(sliderWidth), float64(sliderHeight), sliderColor)
 	ebitenutil.DrawRect(screen, float64(sliderX + g.renderer.sliderReadX - 5), float64(sliderY - 5), 10, float64(sliderHeight + 10), sliderHandleColor)
 
 	// Draw broadcast rate slider
-	text.Draw(screen, "Broadcast Rate: " + strconv.FormatInt(g.renderer.broadcastRate, 10) + "ms", g.renderer.fontFace, sliderX, sliderY + 40, color.White)
+	text.Draw(screen, label + "Broadcast Rate: " + strconv.FormatInt(broadcastRate, 10) + "ms", g.renderer.fontFace, sliderX, sliderY + 40, color.White)
 	ebitenutil.DrawRect(screen, float64(sliderX), float64(sliderY + 45), float64(sliderWidth), float64(sliderHeight), sliderColor)
 	ebitenutil.DrawRect(screen, float64(sliderX + g.renderer.sliderBroadcastX - 5), float64(sliderY + 40), 10, float64(sliderHeight + 10), sliderHandleColor)
 }
 
 func (g *EbitenGame) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return g.renderer.width * g.renderer.cellSize, g.renderer.height * g.renderer.cellSize
+	width := g.renderer.width * g.renderer.cellSize
+	if g.renderer.layout == HexLayout {
+		// Leave room for the half cell shift of odd rows
+		width += g.renderer.cellSize / 2
+	}
+	return width, g.renderer.height * g.renderer.cellSize
 }
 
 // EbitenRenderer implements the Renderer interface using Ebiten
@@ -330,8 +384,11 @@ type EbitenRenderer struct {
 	buffer         [][]string
 	communications [][]bool // Tracks which cells have communicated
 	deadCellBroadcasts [][]bool // Tracks broadcasts to dead cells
-	fadingCells    [][]float64 // Tracks cells that are fading out (0.0 to 1.0, where 0.0 is fully faded)
-	lastFrameTime  time.Time // Used to calculate time delta for fading
+	fadingCells    [][]float64 // Opacity of cells in a decaying state (0.0 to 1.0, where 0.0 is fully faded)
+	values         [][]float64 // Continuous cell states drawn with DrawValueAt
+	regions        [][]regionRate // Rates painted onto cells, zero where the global rates apply
+	linkFlashes    [][]linkFlash // Latest link fault on a message to each cell, guarded by charMutex
+	field          bool // Set once any value is drawn, switches the grid to a colormap
 	statsWindows   []*EbitenStatsWindow
 	charBuffer     []Key
 	charMutex      sync.Mutex
@@ -339,12 +396,20 @@ type EbitenRenderer struct {
 	mousePressed   bool
 	game           *EbitenGame
 	fontFace       font.Face
+	layout         Layout
+	palette        Palette
 
 	// Rate control
 	readRate       int64
 	broadcastRate  int64
 	rateCallback   func(readRate, broadcastRate int64)
 
+	// Rate paint mode
+	ratePaint          bool
+	brushReadRate      int64
+	brushBroadcastRate int64
+	regionCallback     func(y, x int, readRate, broadcastRate int64)
+
 	// Slider state
 	sliderReadX    int
 	sliderBroadcastX int
@@ -381,12 +446,16 @@ func NewEbitenRenderer(padding int) Renderer {
 		communications: make([][]bool, height),
 		deadCellBroadcasts: make([][]bool, height),
 		fadingCells:    make([][]float64, height),
-		lastFrameTime:  time.Now(),
+		values:         make([][]float64, height),
+		regions:        make([][]regionRate, height),
+		linkFlashes:    make([][]linkFlash, height),
 		statsWindows:   make([]*EbitenStatsWindow, 0),
 		charBuffer:     make([]Key, 0),
 		fontFace:       basicfont.Face7x13,
 		readRate:       500, // Default read rate in milliseconds
 		broadcastRate:  500, // Default broadcast rate in milliseconds
+		brushReadRate:  500,
+		brushBroadcastRate: 500,
 		sliderReadX:    150, // Initial slider position
 		sliderBroadcastX: 150, // Initial slider position
 	}
@@ -397,6 +466,9 @@ func NewEbitenRenderer(padding int) Renderer {
 		r.communications[i] = make([]bool, width)
 		r.deadCellBroadcasts[i] = make([]bool, width)
 		r.fadingCells[i] = make([]float64, width)
+		r.values[i] = make([]float64, width)
+		r.regions[i] = make([]regionRate, width)
+		r.linkFlashes[i] = make([]linkFlash, width)
 	}
 
 	r.game = &EbitenGame{renderer: r}
@@ -418,13 +490,18 @@ func (r *EbitenRenderer) Start() {
 	r.communications = make([][]bool, r.height)
 	r.deadCellBroadcasts = make([][]bool, r.height)
 	r.fadingCells = make([][]float64, r.height)
+	r.values = make([][]float64, r.height)
+	r.regions = make([][]regionRate, r.height)
+	r.linkFlashes = make([][]linkFlash, r.height)
 	for i := range r.buffer {
 		r.buffer[i] = make([]string, r.width)
 		r.communications[i] = make([]bool, r.width)
 		r.deadCellBroadcasts[i] = make([]bool, r.width)
 		r.fadingCells[i] = make([]float64, r.width)
+		r.values[i] = make([]float64, r.width)
+		r.regions[i] = make([]regionRate, r.width)
+		r.linkFlashes[i] = make([]linkFlash, r.width)
 	}
-	r.lastFrameTime = time.Now()
 	glog.GetLogger().Info("Starting Ebiten Window", "height", r.height, "width", r.width)
 }
 
@@ -442,21 +519,11 @@ func (r *EbitenRenderer) Draw(str string) {
 
 func (r *EbitenRenderer) DrawAt(y, x int, ach string) {
 	if y >= 0 && y < len(r.buffer) && x >= 0 && x < len(r.buffer[y]) {
-		// Check if the cell was previously alive and is now not alive
-		if r.buffer[y][x] == "0" && ach != "0" {
-			// Start fading out the cell
-			r.fadingCells[y][x] = 1.0 // Start with full opacity
-		}
-
 		r.buffer[y][x] = ach
-		// Mark cell as communicating if it's alive (represented by "0")
-		if ach == "0" {
-			r.communications[y][x] = true
-			// Reset fading opacity when a cell becomes alive
-			r.fadingCells[y][x] = 0.0
-		} else {
-			r.communications[y][x] = false
-		}
+		// Decaying states carry their opacity in the palette, everything else has none
+		r.fadingCells[y][x] = r.palette[ach].Fade
+		// Mark cell as communicating if it's alive
+		r.communications[y][x] = r.alive(ach)
 	}
 }
 
@@ -479,6 +546,7 @@ func (r *EbitenRenderer) Clear() {
 			r.communications[i][j] = false
 			r.deadCellBroadcasts[i][j] = false
 			r.fadingCells[i][j] = 0.0 // Reset fading cells
+			r.values[i][j] = 0.0
 		}
 	}
 }
@@ -496,12 +564,122 @@ func (r *EbitenRenderer) GetChar() Key {
 }
 
 func (r *EbitenRenderer) GetMouse() MouseEvent {
+	return r.cellAt(r.mouseX, r.mouseY)
+}
+
+// cellAt finds the cell under a pixel, undoing the half cell shift of odd hex rows
+func (r *EbitenRenderer) cellAt(pixelX, pixelY int) MouseEvent {
+	y := pixelY / r.cellSize
+	if r.layout == HexLayout && y%2 == 1 {
+		pixelX -= r.cellSize / 2
+	}
 	return MouseEvent{
-		X: r.mouseX / r.cellSize,
-		Y: r.mouseY / r.cellSize,
+		X: pixelX / r.cellSize,
+		Y: y,
 	}
 }
 
+// SetLayout switches between square and hex grids
+func (r *EbitenRenderer) SetLayout(layout Layout) {
+	r.layout = layout
+}
+
+// SetPalette chooses the fill color for each glyph. Live glyphs missing from
+// the palette are drawn off-white.
+func (r *EbitenRenderer) SetPalette(palette Palette) {
+	r.palette = palette
+}
+
+// DrawValueAt records a continuous state between 0 and 1, drawn as a colormap
+// instead of glyphs
+func (r *EbitenRenderer) DrawValueAt(y, x int, value float64) {
+	if y >= 0 && y < len(r.values) && x >= 0 && x < len(r.values[y]) {
+		r.field = true
+		r.values[y][x] = value
+	}
+}
+
+// linkFlash is a link fault still fading out on the overlay
+type linkFlash struct {
+	fromY, fromX int
+	fault        LinkFault
+	frames       int
+}
+
+// linkFlashFrames is how many frames a link fault stays visible
+const linkFlashFrames = 20
+
+// linkFaultColors draw lost messages red, duplicates yellow, reordered ones
+// magenta and messages stopped at a partition white
+var linkFaultColors = map[LinkFault]color.RGBA{
+	LinkLost:        {255, 48, 48, 255},
+	LinkDuplicated:  {255, 220, 0, 255},
+	LinkReordered:   {224, 64, 255, 255},
+	LinkPartitioned: {240, 240, 240, 255},
+}
+
+// DrawLinkFault flashes the link from (fromY, fromX) to (toY, toX) in the
+// color of the fault. Only the latest fault on messages to each cell is kept.
+func (r *EbitenRenderer) DrawLinkFault(fromY, fromX, toY, toX int, fault LinkFault) {
+	r.charMutex.Lock()
+	defer r.charMutex.Unlock()
+	if toY >= 0 && toY < len(r.linkFlashes) && toX >= 0 && toX < len(r.linkFlashes[toY]) {
+		r.linkFlashes[toY][toX] = linkFlash{fromY: fromY, fromX: fromX, fault: fault, frames: linkFlashFrames}
+	}
+}
+
+// colormapStops run from black through purple, red and orange to pale yellow
+var colormapStops = []color.RGBA{
+	{0, 0, 4, 255},
+	{87, 16, 110, 255},
+	{188, 55, 84, 255},
+	{249, 142, 9, 255},
+	{252, 255, 164, 255},
+}
+
+// colormap interpolates colormapStops for a value between 0 and 1
+func colormap(value float64) color.RGBA {
+	value = math.Max(0, math.Min(1, value))
+	position := value * float64(len(colormapStops)-1)
+	i := int(position)
+	if i >= len(colormapStops)-1 {
+		return colormapStops[len(colormapStops)-1]
+	}
+	t := position - float64(i)
+	from, to := colormapStops[i], colormapStops[i+1]
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + t*(float64(b)-float64(a)))
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 255}
+}
+
+// alive reports whether a buffered glyph is a live cell of any state. Decaying
+// glyphs are drawn faded and are not live.
+func (r *EbitenRenderer) alive(glyph string) bool {
+	return glyph != DeadGlyph && glyph != "" && r.palette[glyph].Fade == 0
+}
+
+// cellOrigin returns the top left pixel of the cell at (y, x), shifting odd rows for hex grids
+func (r *EbitenRenderer) cellOrigin(y, x int) (float64, float64) {
+	cellX := float64(x * r.cellSize)
+	if r.layout == HexLayout && y%2 == 1 {
+		cellX += float64(r.cellSize) / 2
+	}
+	return cellX, float64(y * r.cellSize)
+}
+
+// adjacentOffsets lists the cells touching a cell in row y, which the
+// communication overlay draws lines to
+func (r *EbitenRenderer) adjacentOffsets(y int) [][2]int {
+	if r.layout == HexLayout {
+		if y%2 == 0 {
+			return [][2]int{{-1, -1}, {-1, 0}, {0, -1}, {0, 1}, {1, -1}, {1, 0}}
+		}
+		return [][2]int{{-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, 0}, {1, 1}}
+	}
+	return [][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+}
+
 func (r *EbitenRenderer) MouseSupport() bool {
 	return true
 }
@@ -534,13 +712,83 @@ func (r *EbitenRenderer) SetRateChangeCallback(callback func(readRate, broadcast
 	r.rateCallback = callback
 }
 
+// SetRegionRateCallback sets the callback for every cell painted in rate
+// paint mode. Rates of zero hand the cell back to the global rates.
+func (r *EbitenRenderer) SetRegionRateCallback(callback func(y, x int, readRate, broadcastRate int64)) {
+	r.regionCallback = callback
+}
+
 // SetInitialRates sets the initial read and broadcast rates
 func (r *EbitenRenderer) SetInitialRates(readRate, broadcastRate int64) {
 	r.readRate = readRate
 	r.broadcastRate = broadcastRate
+	r.brushReadRate = readRate
+	r.brushBroadcastRate = broadcastRate
+	r.syncSliders()
+}
+
+// syncSliders moves the slider handles to the rates they currently control,
+// the global ones or in rate paint mode the ones painted onto regions
+func (r *EbitenRenderer) syncSliders() {
+	readRate, broadcastRate := r.readRate, r.broadcastRate
+	if r.ratePaint {
+		readRate, broadcastRate = r.brushReadRate, r.brushBroadcastRate
+	}
 
-	// Update slider positions based on the rates
 	sliderWidth := 300 // Must match the width used in the Draw method
 	r.sliderReadX = int(readRate * int64(sliderWidth) / 1000)
 	r.sliderBroadcastX = int(broadcastRate * int64(sliderWidth) / 1000)
 }
+
+// applySliders reads the rates back from the slider handles. Only the global
+// rates are reported to the rate change callback.
+func (r *EbitenRenderer) applySliders(sliderWidth int) {
+	readRate := int64(r.sliderReadX * 1000 / sliderWidth)
+	broadcastRate := int64(r.sliderBroadcastX * 1000 / sliderWidth)
+	if r.ratePaint {
+		// A zero rate would hand the region back to the global rate
+		r.brushReadRate, r.brushBroadcastRate = max(readRate, 1), max(broadcastRate, 1)
+		return
+	}
+
+	r.readRate, r.broadcastRate = readRate, broadcastRate
+	if r.rateCallback != nil {
+		r.rateCallback(r.readRate, r.broadcastRate)
+	}
+}
+
+// regionRate is the pair of rates painted onto a single cell
+type regionRate struct {
+	readRate      int64
+	broadcastRate int64
+}
+
+// paintRegion gives every cell within a small circle of the pixel its own
+// rates and reports each of them to the region callback. Zero rates hand the
+// cells back to the global rates, otherwise each rate is at least 1ms so the
+// region keeps its own rates and its tint.
+func (r *EbitenRenderer) paintRegion(pixelX, pixelY int, readRate, broadcastRate int64) {
+	if readRate > 0 || broadcastRate > 0 {
+		readRate, broadcastRate = max(readRate, 1), max(broadcastRate, 1)
+	}
+	center := r.cellAt(pixelX, pixelY)
+	radius := 2
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			y, x := center.Y+dy, center.X+dx
+			if dx*dx+dy*dy > radius*radius || y < 0 || y >= len(r.regions) || x < 0 || x >= len(r.regions[y]) {
+				continue
+			}
+			r.regions[y][x] = regionRate{readRate: readRate, broadcastRate: broadcastRate}
+			if r.regionCallback != nil {
+				r.regionCallback(y, x, readRate, broadcastRate)
+			}
+		}
+	}
+}
+
+// regionTint shades painted regions from orange for fast reads to blue for slow ones
+func regionTint(readRate int64) color.RGBA {
+	t := math.Max(0, math.Min(1, float64(readRate)/1000))
+	return color.RGBA{uint8(255 * (1 - t)), uint8(140 - 20*t), uint8(255 * t), 70}
+}
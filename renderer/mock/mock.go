@@ -1,7 +1,6 @@
 package mock
 
 import (
-	"github.com/gbin/goncurses"
 	"github.com/ninjapanzer/gogol_channels/renderer"
 	"log/slog"
 )
@@ -47,6 +46,58 @@ func (s *Renderer) BufferUpdate() {
 
 func (s *Renderer) Clear() {}
 
-func (s *Renderer) GetChar() goncurses.Key {
+func (s *Renderer) GetChar() renderer.Key {
 	return 0
 }
+
+func (s *Renderer) GetMouse() renderer.MouseEvent {
+	return renderer.MouseEvent{}
+}
+
+func (s *Renderer) MouseSupport() bool {
+	return false
+}
+
+func (s *Renderer) CreateStatsWindow(height, width, y, x int) renderer.StatsWindow {
+	return &StatsWindow{}
+}
+
+func (s *Renderer) SetLayout(layout renderer.Layout) {}
+
+func (s *Renderer) SetPalette(palette renderer.Palette) {}
+
+func (s *Renderer) DrawValueAt(y, x int, value float64) {
+	slog.Debug("DrawValueAt")
+}
+
+func (s *Renderer) DrawLinkFault(fromY, fromX, toY, toX int, fault renderer.LinkFault) {
+	slog.Debug("DrawLinkFault")
+}
+
+func (s *Renderer) GetReadRate() int64 {
+	return 0
+}
+
+func (s *Renderer) GetBroadcastRate() int64 {
+	return 0
+}
+
+func (s *Renderer) SetRateChangeCallback(func(readRate, broadcastRate int64)) {}
+
+func (s *Renderer) SetInitialRates(readRate, broadcastRate int64) {}
+
+func (s *Renderer) SetRegionRateCallback(func(y, x int, readRate, broadcastRate int64)) {}
+
+type StatsWindow struct{}
+
+func (sw *StatsWindow) MovePrint(y, x int, str string) {
+	slog.Debug("Stats", "line", str)
+}
+
+func (sw *StatsWindow) Clear() {}
+
+func (sw *StatsWindow) NoutRefresh() {}
+
+func (sw *StatsWindow) Delete() error {
+	return nil
+}
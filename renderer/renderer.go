@@ -1,5 +1,7 @@
 package renderer
 
+import "image/color"
+
 // Key represents a keyboard or mouse input
 type Key int
 
@@ -7,6 +9,53 @@ type Key int
 const (
 	KEY_MOUSE = 409 // Same as goncurses.KEY_MOUSE
 	KEY_MOUSE_RELEASE = 410 // Custom key for mouse release events
+	KEY_PAUSE = ' ' // Toggles pause/resume of the simulation
+	KEY_STEP = 'n' // Advances a paused simulation by one read cycle
+	KEY_BRUSH = 'b' // Mouse paints random clusters of live cells
+	KEY_ERASE = 'x' // Mouse erases single cells
+	KEY_CONDUCTOR = 'c' // Mouse paints WireWorld conductor
+	KEY_HEAD = 'e' // Mouse paints WireWorld electron heads
+	KEY_TAIL = 't' // Mouse paints WireWorld electron tails
+)
+
+// Glyphs used for classic two state Life. Any glyph other than DeadGlyph is
+// treated as a live cell by the communication overlay.
+const (
+	AliveGlyph = "0"
+	DeadGlyph  = "-"
+)
+
+// Style is how a single glyph is drawn. A Fade above zero marks a decaying
+// cell, such as the refractory states of a Generations rule, which is drawn at
+// that opacity (0 to 1) instead of as a live cell.
+type Style struct {
+	Color color.RGBA
+	Fade  float64
+}
+
+// Palette maps the glyphs a world draws to the style used to render them.
+// Glyphs missing from the palette fall back to the renderer's defaults.
+type Palette map[string]Style
+
+// LinkFault is something an unreliable link between two cells did to a
+// message, shown on the communications overlay
+type LinkFault int
+
+const (
+	LinkLost LinkFault = iota + 1
+	LinkDuplicated
+	LinkReordered
+	// LinkPartitioned marks a message stopped by a network partition
+	LinkPartitioned
+)
+
+// Layout describes how the grid of cells is arranged on screen
+type Layout int
+
+const (
+	SquareLayout Layout = iota
+	// HexLayout shifts every odd row right by half a cell ("odd-r" offset coordinates)
+	HexLayout
 )
 
 // MouseEvent represents a mouse event
@@ -18,6 +67,10 @@ type Renderer interface {
 	Beep()
 	Draw(string)
 	DrawAt(int, int, string)
+	// DrawValueAt draws a continuous cell state between 0 and 1
+	DrawValueAt(y, x int, value float64)
+	// DrawLinkFault flashes the link a message from one cell to another crossed
+	DrawLinkFault(fromY, fromX, toY, toX int, fault LinkFault)
 	Dimensions() (y int, x int)
 	Start()
 	End()
@@ -28,11 +81,16 @@ type Renderer interface {
 	GetMouse() MouseEvent
 	MouseSupport() bool
 	CreateStatsWindow(height, width, y, x int) StatsWindow
+	SetLayout(Layout)
+	SetPalette(Palette)
 	// Methods for rate control
 	GetReadRate() int64
 	GetBroadcastRate() int64
 	SetRateChangeCallback(func(readRate, broadcastRate int64))
 	SetInitialRates(readRate, broadcastRate int64)
+	// SetRegionRateCallback is called for every cell painted with its own rates.
+	// Rates of zero hand the cell back to the global rates.
+	SetRegionRateCallback(func(y, x int, readRate, broadcastRate int64))
 }
 
 // StatsWindow represents a window for displaying statistics
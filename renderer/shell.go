@@ -1,6 +1,8 @@
 package renderer
 
 import (
+	"image/color"
+
 	"github.com/gbin/goncurses"
 	glog "github.com/ninjapanzer/gogol_channels/log"
 )
@@ -31,6 +33,7 @@ type ShellRenderer struct {
 	wrapper *goncurses.Window
 	Display *goncurses.Window
 	Padding int
+	pairs   map[string]int16 // color pair for each glyph in the palette
 
 	// Rate control
 	readRate      int64
@@ -109,9 +112,27 @@ func (s *ShellRenderer) Draw(str string) {
 }
 
 func (s *ShellRenderer) DrawAt(y, x int, ach string) {
+	if pair, ok := s.pairs[ach]; ok {
+		s.Display.ColorOn(pair)
+		defer s.Display.ColorOff(pair)
+	}
 	s.Display.MovePrint(y, x, ach)
 }
 
+// densityRamp runs from empty to full, one character per band of values
+const densityRamp = " .:-=+*#%@"
+
+// DrawValueAt draws a continuous state as a character from densityRamp
+func (s *ShellRenderer) DrawValueAt(y, x int, value float64) {
+	i := int(value * float64(len(densityRamp)))
+	i = max(0, min(len(densityRamp)-1, i))
+	s.Display.MovePrint(y, x, densityRamp[i:i+1])
+}
+
+// DrawLinkFault does nothing, the shell renderer has no communications overlay
+func (s *ShellRenderer) DrawLinkFault(fromY, fromX, toY, toX int, fault LinkFault) {
+}
+
 func (s *ShellRenderer) Beep() {
 	goncurses.Beep()
 }
@@ -152,6 +173,45 @@ func (s *ShellRenderer) CreateStatsWindow(height, width, y, x int) StatsWindow {
 	return &ShellStatsWindow{window: window}
 }
 
+// SetLayout is a no-op, a terminal cannot offset rows by half a character so
+// hex grids are drawn as squares
+func (s *ShellRenderer) SetLayout(layout Layout) {}
+
+// SetPalette assigns a color pair to every glyph in palette. Terminals without
+// color support keep drawing in the default color.
+func (s *ShellRenderer) SetPalette(palette Palette) {
+	if !goncurses.HasColors() {
+		return
+	}
+	if err := goncurses.StartColor(); err != nil {
+		glog.GetLogger().Warn("Unable to start colors", "error", err)
+		return
+	}
+
+	s.pairs = make(map[string]int16, len(palette))
+	pair := int16(1)
+	for glyph, style := range palette {
+		if err := goncurses.InitPair(pair, nearestColor(style.Color), goncurses.C_BLACK); err != nil {
+			glog.GetLogger().Warn("Unable to create color pair", "glyph", glyph, "error", err)
+			continue
+		}
+		s.pairs[glyph] = pair
+		pair++
+	}
+}
+
+// nearestColor picks the closest of the eight basic curses colors, whose
+// numbers are a red, green and blue bit each
+func nearestColor(c color.RGBA) int16 {
+	bit := func(v uint8) int16 {
+		if v >= 128 {
+			return 1
+		}
+		return 0
+	}
+	return bit(c.R) | bit(c.G)<<1 | bit(c.B)<<2
+}
+
 // GetReadRate returns the current read rate
 func (s *ShellRenderer) GetReadRate() int64 {
 	return s.readRate
@@ -167,6 +227,10 @@ func (s *ShellRenderer) SetRateChangeCallback(callback func(readRate, broadcastR
 	s.rateCallback = callback
 }
 
+// SetRegionRateCallback does nothing, the shell renderer has no rate paint tool
+func (s *ShellRenderer) SetRegionRateCallback(callback func(y, x int, readRate, broadcastRate int64)) {
+}
+
 // SetInitialRates sets the initial read and broadcast rates
 func (s *ShellRenderer) SetInitialRates(readRate, broadcastRate int64) {
 	s.readRate = readRate